@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+// pathKeys are the keys of the configuration file whose relative paths are resolved
+// relative to the directory of the configuration file.
+var pathKeys = map[string]bool{
+	"directory":          true,
+	"output-dir":         true,
+	"output":             true,
+	"template-directory": true,
+	"cache-dir":          true,
+	"header-template":    true,
+	"reference-base":     true,
+}
+
+// excludedKeys are flags that cannot be set in a configuration file.
+var excludedKeys = map[string]bool{
+	"config":  true,
+	"help":    true,
+	"version": true,
+}
+
+// applyConfigFile sets the flags and the targets from the configuration file.
+// The keys of the file are the long names of the flags. Flags set on the command line take precedence.
+func applyConfigFile(fs *pflag.FlagSet) error {
+	path, err := fs.GetString("config")
+	if err != nil {
+		return err
+	}
+
+	if path == "" {
+		dir := extractCfg.SourceDir
+		if dir == "" {
+			dir = "."
+		}
+		if path = config.FindFile(dir); path == "" {
+			return nil
+		}
+	}
+
+	file, err := config.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	extractCfg.Targets = file.Targets
+
+	baseDir := filepath.Dir(path)
+	for _, fv := range file.Values {
+		flag := fs.Lookup(fv.Key)
+		if flag == nil || excludedKeys[fv.Key] {
+			return fmt.Errorf("%s: unknown key %q, the keys are the long names of the flags listed by --help", path, fv.Key)
+		}
+
+		if flag.Changed {
+			continue
+		}
+
+		list := fv.Values
+		if !fv.IsList {
+			list = []string{fv.Value}
+		}
+		if pathKeys[fv.Key] {
+			for i, value := range list {
+				if value != "" && !filepath.IsAbs(value) {
+					list[i] = filepath.Join(baseDir, value)
+				}
+			}
+		}
+
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			err = sliceValue.Replace(list)
+		} else if fv.IsList {
+			err = fmt.Errorf("a single value is expected, got a list")
+		} else {
+			err = flag.Value.Set(list[0])
+		}
+		if err != nil {
+			return fmt.Errorf("%s: key %q: %w", path, fv.Key, err)
+		}
+	}
+
+	log.Debugf("Configuration file loaded: %s", path)
+	return nil
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+func parseWithConfigFile(t *testing.T, content string, args ...string) error {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "xspreak.yaml"), []byte(content), 0o600))
+
+	extractCfg = config.NewDefault()
+	t.Cleanup(func() { extractCfg = config.NewDefault() })
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	addExtractFlags(fs)
+	require.NoError(t, fs.Parse(append([]string{"-D", dir}, args...)))
+	return applyConfigFile(fs)
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	content := `
+extract-errors: true
+errors-context: failures
+default-domain: app
+output-dir: locale
+add-comments: [TRANSLATORS, NOTE]
+template-directory:
+  - "templates/**/*.html"
+`
+
+	t.Run("file values", func(t *testing.T) {
+		require.NoError(t, parseWithConfigFile(t, content))
+		assert.True(t, extractCfg.ExtractErrors)
+		assert.Equal(t, "failures", extractCfg.ErrorContext)
+		assert.Equal(t, "app", extractCfg.DefaultDomain)
+		assert.Equal(t, []string{"TRANSLATORS", "NOTE"}, extractCfg.CommentPrefixes)
+		assert.Equal(t, filepath.Join(extractCfg.SourceDir, "locale"), extractCfg.OutputDir)
+		assert.Equal(t, []string{filepath.Join(extractCfg.SourceDir, "templates/**/*.html")}, extractCfg.TemplatePatterns)
+	})
+
+	t.Run("flags override the file", func(t *testing.T) {
+		require.NoError(t, parseWithConfigFile(t, content, "-d", "cli", "--errors-context", "cli-errors"))
+		assert.Equal(t, "cli", extractCfg.DefaultDomain)
+		assert.Equal(t, "cli-errors", extractCfg.ErrorContext)
+		assert.True(t, extractCfg.ExtractErrors)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		err := parseWithConfigFile(t, "extract-erros: true\n")
+		assert.ErrorContains(t, err, `unknown key "extract-erros"`)
+	})
+
+	t.Run("list for single value", func(t *testing.T) {
+		err := parseWithConfigFile(t, "default-domain: [a, b]\n")
+		assert.ErrorContains(t, err, `key "default-domain"`)
+	})
+}
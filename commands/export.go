@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
+
+	"github.com/vorlif/xspreak/encoder"
+	"github.com/vorlif/xspreak/importer"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export translation files side by side into a CSV or TSV file",
+	Long: `Export writes the messages of a template and the translations of PO or JSON files into a CSV or TSV file,
+which can be edited in a spreadsheet and imported again with "xspreak import".
+The format of the output file is determined by its extension, .csv or .tsv.
+The language of a translation file is taken from its header or its file name,
+it can be set explicitly with lang=path.
+If a template is given, the file contains the messages of the template, otherwise of all translation files.`,
+	Run: exportCmdF,
+	Example: `  xspreak export -t locale/messages.pot -i locale/de.po -i locale/fr.po -o review.csv
+  xspreak export -i pt-BR=locale/messages.pt.json -o review.tsv`,
+}
+
+func init() {
+	fs := exportCmd.Flags()
+	fs.SortFlags = false
+	fs.StringArrayP("input", "i", nil, "translation file, .po or .json, can be specified multiple times")
+	fs.StringP("template", "t", "", "template file, .pot, .po or .json")
+	fs.StringP("output", "o", "", "output file, .csv or .tsv")
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+func exportCmdF(cmd *cobra.Command, _ []string) {
+	inputs, errI := cmd.Flags().GetStringArray("input")
+	if errI != nil {
+		log.WithError(errI).Fatal("Invalid source file")
+	}
+
+	templatePath, errT := cmd.Flags().GetString("template")
+	if errT != nil {
+		log.WithError(errT).Fatal("Invalid template file")
+	} else if templatePath == "" && len(inputs) == 0 {
+		log.Fatal("Template or source required")
+	}
+
+	dstPath, errD := cmd.Flags().GetString("output")
+	if errD != nil {
+		log.WithError(errD).Fatal("Invalid destination file")
+	} else if dstPath == "" {
+		log.Fatal("Destination required")
+	}
+
+	ext := strings.ToLower(filepath.Ext(dstPath))
+	if ext != ".csv" && ext != ".tsv" {
+		log.Fatalf("Unknown output format %q, use .csv or .tsv", ext)
+	}
+
+	var template *importer.Catalog
+	if templatePath != "" {
+		template = readCatalog(templatePath, "")
+	}
+
+	catalogs := make([]*importer.Catalog, 0, len(inputs))
+	for _, input := range inputs {
+		lang, path, found := strings.Cut(input, "=")
+		if !found {
+			lang, path = "", input
+		}
+
+		if lang == "" {
+			lang = fileLanguage(path)
+		}
+		if _, err := language.Parse(lang); err != nil {
+			log.WithError(err).Fatalf("Language of %s could not be parsed, set it with lang=path", path)
+		}
+		catalogs = append(catalogs, readCatalog(path, lang))
+	}
+
+	var buf bytes.Buffer
+	if err := importer.EncodeCSV(&buf, encoder.CSVComma(strings.TrimPrefix(ext, ".")), template, catalogs); err != nil {
+		log.WithError(err).Fatal("Target file could not be encoded")
+	}
+
+	if err := os.WriteFile(dstPath, buf.Bytes(), 0666); err != nil {
+		log.WithError(err).Fatal("Target file could not be written")
+	}
+	log.Printf("Target file written %s\n", dstPath)
+}
+
+// fileLanguage returns the language of the PO header of a file or, if there is none, its file name.
+func fileLanguage(path string) string {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".po" || ext == ".pot" {
+		if header, err := encoder.ReadHeader(path); err == nil && header != nil && header.Language != "" {
+			return header.Language
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// readCatalog reads a PO or JSON file. If lang is empty, the language of the PO header is used.
+func readCatalog(path, lang string) *importer.Catalog {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.WithError(err).Fatalf("File %s could not be read", path)
+	}
+
+	var catalog *importer.Catalog
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".po", ".pot":
+		catalog, err = importer.DecodePO(content, lang)
+	case ".json":
+		catalog, err = importer.DecodeJSON(content, lang)
+	default:
+		log.Fatalf("Unknown format %q of %s, use .po, .pot or .json", ext, path)
+	}
+	if err != nil {
+		log.WithError(err).Fatalf("File %s could not be decoded", path)
+	}
+	return catalog
+}
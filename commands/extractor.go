@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -9,6 +10,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/vorlif/spreak/catalog/po"
+
 	"github.com/vorlif/xspreak/config"
 	"github.com/vorlif/xspreak/encoder"
 	"github.com/vorlif/xspreak/extract"
@@ -23,26 +26,62 @@ type Extractor struct {
 	cfg *config.Config
 	log *log.Entry
 
-	contextLoader *loader.PackageLoader
+	// loaders contains one loader per build configuration.
+	loaders []*loader.PackageLoader
+	// outputs contains one output per target, or a single output without targets.
+	outputs []*output
+
+	// skipUnchanged prevents files from being written if only the creation date would change.
+	skipUnchanged bool
 }
 
 func NewExtractor() *Extractor {
-	return &Extractor{
-		cfg:           extractCfg,
-		log:           log.WithField("service", "extractor"),
-		contextLoader: loader.NewPackageLoader(extractCfg),
+	e := &Extractor{
+		cfg: extractCfg,
+		log: log.WithField("service", "extractor"),
 	}
+
+	for _, buildCfg := range extractCfg.BuildConfigs() {
+		e.loaders = append(e.loaders, loader.NewPackageLoader(buildCfg))
+	}
+
+	if len(extractCfg.Targets) == 0 {
+		e.outputs = []*output{{cfg: extractCfg}}
+	}
+	for _, target := range extractCfg.Targets {
+		e.outputs = append(e.outputs, newOutput(extractCfg, target))
+	}
+
+	return e
 }
 
 func (e *Extractor) extract() {
 	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.Timeout)
 	defer cancel()
 
-	extractedIssues, errE := e.runExtraction(ctx)
+	if err := e.run(ctx); err != nil {
+		e.log.Fatalf("Running error: %s", err)
+	}
+}
+
+// run extracts the strings and saves the files of all domains of all outputs.
+func (e *Extractor) run(ctx context.Context) error {
+	outputIssues, errE := e.runExtraction(ctx)
 	if errE != nil {
-		e.log.Fatalf("Running error: %s", errE)
+		return errE
+	}
+
+	for i, out := range e.outputs {
+		if err := e.saveOutput(out, outputIssues[i]); err != nil {
+			return err
+		}
 	}
 
+	return nil
+}
+
+// saveOutput sorts the issues of an output by domain and saves them.
+func (e *Extractor) saveOutput(out *output, extractedIssues []extract.Issue) error {
 	domainIssues := make(map[string][]extract.Issue)
 	start := time.Now()
 	for _, iss := range extractedIssues {
@@ -56,13 +95,18 @@ func (e *Extractor) extract() {
 
 	if len(extractedIssues) == 0 {
 		domainIssues[""] = make([]extract.Issue, 0)
-		log.Println("No Strings found")
+		if out.name != "" {
+			log.Printf("No Strings found for target %s", out.name)
+		} else {
+			log.Println("No Strings found")
+		}
 	}
 
-	e.saveDomains(domainIssues)
+	return e.saveDomains(out.cfg, domainIssues)
 }
 
-func (e *Extractor) runExtraction(ctx context.Context) ([]extract.Issue, error) {
+// runExtraction extracts the strings of all build configurations and returns the issues of each output.
+func (e *Extractor) runExtraction(ctx context.Context) ([][]extract.Issue, error) {
 	util.TrackTime(time.Now(), "run all extractors")
 	extractorsToRun := []extract.Extractor{
 		extractors.NewFuncCallExtractor(),
@@ -77,60 +121,164 @@ func (e *Extractor) runExtraction(ctx context.Context) ([]extract.Issue, error)
 		tmplextractors.NewCommandExtractor(),
 	}
 
-	extractCtx, err := e.contextLoader.Load(ctx)
+	// runs contains the issues of each build configuration per output
+	runs := make([][][]extract.Issue, len(e.outputs))
+	for _, contextLoader := range e.loaders {
+		extractCtx, issues, err := e.runBuild(ctx, contextLoader, extractorsToRun)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, out := range e.outputs {
+			runs[i] = append(runs[i], out.selectIssues(extractCtx, issues))
+		}
+	}
+
+	outputIssues := make([][]extract.Issue, len(e.outputs))
+	for i, out := range e.outputs {
+		outputIssues[i] = runner.MergeIssues(runs[i]...)
+		if err := runner.DetectConflicts(out.cfg, outputIssues[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return outputIssues, nil
+}
+
+// runBuild extracts the strings of a single build configuration.
+func (e *Extractor) runBuild(ctx context.Context, contextLoader *loader.PackageLoader, extractorsToRun []extract.Extractor) (*extract.Context, []extract.Issue, error) {
+	extractCtx, err := contextLoader.Load(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("context loading failed: %w", err)
+		return nil, nil, fmt.Errorf("context loading failed: %w", err)
 	}
 
-	runner, err := runner.New(e.cfg, extractCtx.Packages)
+	if len(e.loaders) > 1 {
+		e.log.Debugf("Extracting build configuration %s", extractCtx.Config.Build)
+	}
+
+	r, err := runner.New(extractCtx.Config, extractCtx.ListedPackages)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	issues, err := runner.Run(ctx, extractCtx, extractorsToRun)
+	issues, err := r.Run(ctx, extractCtx, extractorsToRun)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return issues, nil
+	return extractCtx, issues, nil
 }
 
-func (e *Extractor) saveDomains(domains map[string][]extract.Issue) {
+// sourceFiles returns the source files of all build configurations.
+func (e *Extractor) sourceFiles(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, contextLoader := range e.loaders {
+		loaderFiles, err := contextLoader.SourceFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range loaderFiles {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// resetWorkspace makes the loaders read the go.work file again.
+func (e *Extractor) resetWorkspace() {
+	for _, contextLoader := range e.loaders {
+		contextLoader.ResetWorkspace()
+	}
+}
+
+func (e *Extractor) saveDomains(cfg *config.Config, domains map[string][]extract.Issue) error {
 	util.TrackTime(time.Now(), "save files")
 	for domainName, issues := range domains {
 		var outputFile string
 		if domainName == "" {
-			outputFile = filepath.Join(e.cfg.OutputDir, e.cfg.OutputFile)
+			outputFile = filepath.Join(cfg.OutputDir, cfg.OutputFile)
 		} else {
-			outputFile = filepath.Join(e.cfg.OutputDir, domainName+"."+e.cfg.ExtractFormat)
+			outputFile = filepath.Join(cfg.OutputDir, domainName+"."+cfg.ExtractFormat)
 		}
 
 		outputDir := filepath.Dir(outputFile)
 		if _, err := os.Stat(outputDir); os.IsNotExist(err) {
 			log.Printf("Output folder does not exist, trying to create it: %s\n", outputDir)
 			if errC := os.MkdirAll(outputDir, os.ModePerm); errC != nil {
-				log.Fatalf("Output folder does not exist and could not be created: %s", errC)
+				return fmt.Errorf("output folder does not exist and could not be created: %w", errC)
 			}
 		}
 
-		dst, err := os.Create(outputFile)
-		if err != nil {
-			e.log.WithError(err).Fatal("Output file could not be created")
-		}
-		defer dst.Close()
-
+		var buf bytes.Buffer
 		var enc encoder.Encoder
-		if e.cfg.ExtractFormat == config.ExtractFormatPot {
-			enc = encoder.NewPotEncoder(e.cfg, dst)
-		} else {
-			enc = encoder.NewJSONEncoder(dst, "  ")
+		switch cfg.ExtractFormat {
+		case config.ExtractFormatPot:
+			enc = encoder.NewPotEncoderWithHeader(cfg, &buf, previousHeader(cfg, outputFile))
+		case config.ExtractFormatXLIFF:
+			enc = encoder.NewXLIFFEncoder(cfg, &buf)
+		case config.ExtractFormatCSV, config.ExtractFormatTSV:
+			enc = encoder.NewCSVEncoder(cfg, &buf)
+		default:
+			if cfg.JSONLayout == config.JSONLayoutNested {
+				enc = encoder.NewNestedJSONEncoder(&buf, "  ", cfg.JSONSeparator)
+			} else {
+				enc = encoder.NewJSONEncoder(&buf, "  ")
+			}
 		}
 
 		if errEnc := enc.Encode(issues); errEnc != nil {
-			e.log.WithError(errEnc).Fatal("Output file could not be written")
+			return fmt.Errorf("output file could not be written: %w", errEnc)
+		}
+
+		if err := e.writeFile(cfg, outputFile, buf.Bytes()); err != nil {
+			return err
 		}
 
-		_ = dst.Close()
-		log.Printf("File written: %s\n", outputFile)
+		if cfg.ExtractFormat == config.ExtractFormatJSON && cfg.JSONMeta {
+			var metaBuf bytes.Buffer
+			if errEnc := encoder.NewJSONMetaEncoder(cfg, &metaBuf, "  ").Encode(issues); errEnc != nil {
+				return fmt.Errorf("metadata file could not be written: %w", errEnc)
+			}
+			if err := e.writeFile(cfg, encoder.MetaFileName(outputFile), metaBuf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes an output file, unless it is unchanged and unchanged files should be skipped.
+func (e *Extractor) writeFile(cfg *config.Config, path string, content []byte) error {
+	if e.skipUnchanged || cfg.CreationDate == config.CreationDateKeep {
+		if existing, errR := os.ReadFile(path); errR == nil && encoder.EqualContent(existing, content) {
+			log.Debugf("File unchanged: %s", path)
+			return nil
+		}
+	}
+
+	if err := os.WriteFile(path, content, 0o666); err != nil {
+		return fmt.Errorf("output file could not be created: %w", err)
+	}
+	log.Printf("File written: %s\n", path)
+	return nil
+}
+
+// previousHeader returns the header of the existing output file, if the header should be kept.
+func previousHeader(cfg *config.Config, outputFile string) *po.Header {
+	if !cfg.KeepHeader {
+		return nil
+	}
+
+	header, err := encoder.ReadHeader(outputFile)
+	if err != nil {
+		log.WithError(err).Warn("Existing header could not be read, a new header is created")
+		return nil
 	}
+	return header
 }
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vorlif/xspreak/encoder"
+	"github.com/vorlif/xspreak/importer"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Convert translated XLIFF, CSV or TSV files into PO or JSON files",
+	Long: `Import converts a translated XLIFF 1.2 or 2.0, CSV or TSV file into a PO or JSON file that can be loaded by spreak.
+The format of the input file is determined by its extension, .xliff, .xlf, .csv or .tsv,
+the format of the output file by its extension, .po or .json.
+The plural forms are assigned using the CLDR plural rules of the target language of the XLIFF file
+or of the language given with --lang.
+CSV and TSV files can contain several languages, the language to import is selected with --lang.
+All rows are validated and the file is not imported if a row is invalid.`,
+	Run: importCmdF,
+	Example: `  xspreak import -i translated/de.xliff -o locale/de.po
+  xspreak import -i review.csv -o locale/de.json -l de`,
+}
+
+func init() {
+	fs := importCmd.Flags()
+	fs.SortFlags = false
+	fs.StringP("input", "i", "", "XLIFF, CSV or TSV file")
+	fs.StringP("output", "o", "", "output file, .po or .json")
+	fs.StringP("lang", "l", "", "language of the translation (default is the target language of the XLIFF file)")
+
+	rootCmd.AddCommand(importCmd)
+}
+
+func importCmdF(cmd *cobra.Command, _ []string) {
+	srcPath, errS := cmd.Flags().GetString("input")
+	if errS != nil {
+		log.WithError(errS).Fatal("Invalid source file")
+	} else if srcPath == "" {
+		log.Fatal("Source required")
+	}
+
+	dstPath, errD := cmd.Flags().GetString("output")
+	if errD != nil {
+		log.WithError(errD).Fatal("Invalid destination file")
+	} else if dstPath == "" {
+		log.Fatal("Destination required")
+	}
+
+	lang, errL := cmd.Flags().GetString("lang")
+	if errL != nil {
+		log.WithError(errL).Fatal("Invalid language")
+	}
+
+	content, err := os.ReadFile(srcPath)
+	if err != nil {
+		log.WithError(err).Fatal("Source file could not be read")
+	}
+
+	var catalog *importer.Catalog
+	switch ext := strings.ToLower(filepath.Ext(srcPath)); ext {
+	case ".csv", ".tsv":
+		catalog = decodeCSV(content, encoder.CSVComma(strings.TrimPrefix(ext, ".")), lang)
+	default:
+		catalog, err = importer.DecodeXLIFF(content, lang)
+		if err != nil {
+			log.WithError(err).Fatal("Source file could not be decoded")
+		}
+		if catalog.Language == "" {
+			log.Warn("The XLIFF file has no target language, use --lang to assign the plural forms correctly")
+		}
+	}
+
+	writeCatalog(catalog, dstPath)
+}
+
+// decodeCSV reads the catalog of a language from a CSV file.
+// Each invalid row is reported and the import is aborted.
+func decodeCSV(content []byte, comma rune, lang string) *importer.Catalog {
+	catalogs, err := importer.DecodeCSV(content, comma)
+	var rowErrs importer.CSVErrors
+	if errors.As(err, &rowErrs) {
+		for _, rowErr := range rowErrs {
+			entry := log.WithField("line", rowErr.Line)
+			if rowErr.Column != "" {
+				entry = entry.WithField("column", rowErr.Column)
+			}
+			entry.Error(rowErr.Err)
+		}
+		log.Fatalf("Source file contains %d errors", len(rowErrs))
+	} else if err != nil {
+		log.WithError(err).Fatal("Source file could not be decoded")
+	}
+
+	if lang == "" {
+		if len(catalogs) != 1 {
+			log.Fatalf("Source file contains %d languages, select one with --lang", len(catalogs))
+		}
+		return catalogs[0]
+	}
+
+	for _, catalog := range catalogs {
+		if catalog.Language == lang {
+			return catalog
+		}
+	}
+	log.Fatalf("Source file contains no translations for the language %s", lang)
+	return nil
+}
+
+// writeCatalog writes the catalog in the format of the extension of the destination file.
+func writeCatalog(catalog *importer.Catalog, dstPath string) {
+	var buf bytes.Buffer
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(dstPath)); ext {
+	case ".po", ".pot":
+		err = importer.EncodePO(catalog, &buf)
+	case ".json":
+		err = importer.EncodeJSON(catalog, &buf)
+	default:
+		log.Fatalf("Unknown output format %q, use .po or .json", ext)
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Target file could not be encoded")
+	}
+
+	if err = os.WriteFile(dstPath, buf.Bytes(), 0666); err != nil {
+		log.WithError(err).Fatal("Target file could not be written")
+	}
+	log.Printf("Target file written %s\n", dstPath)
+}
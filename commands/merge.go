@@ -9,7 +9,10 @@ import (
 
 	"github.com/vorlif/spreak/catalog/cldrplural"
 
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/encoder"
 	"github.com/vorlif/xspreak/merger"
+	"github.com/vorlif/xspreak/nestedjson"
 )
 
 var mergeCmd = &cobra.Command{
@@ -29,6 +32,8 @@ func init() {
 	fs.StringP("input", "i", "", "source file")
 	fs.StringP("output", "o", "", "output file")
 	fs.StringP("lang", "l", "", "destination language")
+	fs.String("json-layout", config.JSONLayoutFlat, "Layout of the output file: flat or nested")
+	fs.String("json-separator", nestedjson.DefaultSeparator, "Separator at which the keys are split in the nested layout")
 
 	rootCmd.AddCommand(mergeCmd)
 }
@@ -90,9 +95,53 @@ func mergeCmdF(cmd *cobra.Command, _ []string) {
 		}
 	}
 
-	newContent := merger.MergeJSON(sourceContent, destinationContent, ruleSet.Categories)
+	layout, errLayout := cmd.Flags().GetString("json-layout")
+	if errLayout != nil {
+		log.WithError(errLayout).Fatal("Invalid JSON layout")
+	}
+	separator, errSep := cmd.Flags().GetString("json-separator")
+	if errSep != nil {
+		log.WithError(errSep).Fatal("Invalid separator")
+	}
+
+	var newContent []byte
+	switch layout {
+	case config.JSONLayoutFlat:
+		newContent = merger.MergeJSON(sourceContent, destinationContent, ruleSet.Categories)
+	case config.JSONLayoutNested:
+		newContent = merger.MergeNestedJSON(sourceContent, destinationContent, ruleSet.Categories, separator)
+		// The keys of the metadata files are not nested.
+		flatSource, errF := nestedjson.Flatten(sourceContent, separator)
+		if errF != nil {
+			log.WithError(errF).Fatal("Source file could not be decoded")
+		}
+		sourceContent = flatSource
+	default:
+		log.Fatalf("Invalid JSON layout %q, use %q or %q", layout, config.JSONLayoutFlat, config.JSONLayoutNested)
+	}
 	if err := os.WriteFile(dstPath, newContent, 0666); err != nil {
 		log.WithError(err).Fatal("Target file could not be written")
 	}
 	log.Printf("Target file written %s\n", dstPath)
+
+	srcMetaPath, dstMetaPath := encoder.MetaFileName(srcPath), encoder.MetaFileName(dstPath)
+	sourceMeta, destinationMeta := readMetaFile(srcMetaPath), readMetaFile(dstMetaPath)
+	if len(sourceMeta) == 0 && len(destinationMeta) == 0 {
+		return
+	}
+
+	newMeta := merger.MergeJSONMeta(sourceContent, sourceMeta, destinationMeta)
+	if err := os.WriteFile(dstMetaPath, newMeta, 0666); err != nil {
+		log.WithError(err).Fatal("Target metadata file could not be written")
+	}
+	log.Printf("Target metadata file written %s\n", dstMetaPath)
+}
+
+// readMetaFile reads a metadata file, if it does not exist an empty content is returned.
+func readMetaFile(path string) []byte {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Fatal("Metadata file could not be read")
+	}
+	return content
 }
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"path/filepath"
+
+	"github.com/mattn/go-zglob"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/loader"
+)
+
+// output is a set of files written by the extractor.
+// Without targets, there is a single output that receives all issues.
+type output struct {
+	// name is the name of the target, it is empty without targets.
+	name string
+	cfg  *config.Config
+	// target is nil without targets.
+	target *config.Target
+}
+
+func newOutput(cfg *config.Config, target config.Target) *output {
+	return &output{
+		name:   target.Name,
+		cfg:    cfg.TargetConfig(target),
+		target: &target,
+	}
+}
+
+// selectIssues returns the issues of the output.
+// A target receives the issues of the Go files of its packages and of its template files.
+func (o *output) selectIssues(extractCtx *extract.Context, issues []extract.Issue) []extract.Issue {
+	if o.target == nil {
+		return issues
+	}
+
+	var goFiles map[string]bool
+	if len(o.target.Packages) > 0 {
+		goFiles = loader.TargetFiles(extractCtx, o.target.Packages)
+	}
+
+	selected := make([]extract.Issue, 0, len(issues))
+	for _, iss := range issues {
+		if goFiles[iss.Pos.Filename] || o.isTemplateFile(iss.Pos.Filename) {
+			selected = append(selected, iss)
+		}
+	}
+
+	return selected
+}
+
+func (o *output) isTemplateFile(filename string) bool {
+	for _, pattern := range o.target.TemplatePatterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(o.cfg.CurrentDir, pattern)
+		}
+		if matched, _ := zglob.Match(pattern, filename); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/vorlif/xspreak/config"
 	"github.com/vorlif/xspreak/tmpl"
@@ -37,9 +42,22 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&extractCfg.IsVerbose, "verbose", "V", def.IsVerbose, "increase verbosity level")
 	rootCmd.PersistentFlags().DurationVar(&extractCfg.Timeout, "timeout", def.Timeout, "Timeout for total work")
 
-	fs := rootCmd.Flags()
+	addExtractFlags(rootCmd.Flags())
+}
+
+// addExtractFlags adds the flags which configure the extraction to a command.
+func addExtractFlags(fs *pflag.FlagSet) {
+	def := config.NewDefault()
+
 	fs.SortFlags = false
-	fs.StringVarP(&extractCfg.ExtractFormat, "format", "f", def.ExtractFormat, "Output format of the extraction. Valid values are 'pot' and 'json'.")
+	fs.String("config", "", "Configuration file (default is xspreak.yaml, xspreak.toml or the hidden variants in the source directory)")
+	fs.StringVarP(&extractCfg.ExtractFormat, "format", "f", def.ExtractFormat, "Output format of the extraction. Valid values are 'pot', 'json', 'xliff', 'csv' and 'tsv'.")
+	fs.BoolVar(&extractCfg.JSONMeta, "json-meta", def.JSONMeta, "Write the comments, references and flags of JSON files to a *.meta.json file")
+	fs.StringVar(&extractCfg.JSONLayout, "json-layout", def.JSONLayout, "Layout of JSON files: flat or nested, nested splits the keys into nested objects")
+	fs.StringVar(&extractCfg.JSONSeparator, "json-separator", def.JSONSeparator, "Separator at which the keys are split in the nested JSON layout")
+	fs.StringVar(&extractCfg.XLIFFVersion, "xliff-version", def.XLIFFVersion, "Version of XLIFF files, 1.2 or 2.0")
+	fs.StringVar(&extractCfg.SourceLanguage, "source-language", def.SourceLanguage, "Language of the extracted strings")
+	fs.StringVar(&extractCfg.TargetLanguage, "target-language", def.TargetLanguage, "Language of the translation, XLIFF and CSV files contain its plural forms")
 	fs.StringVarP(&extractCfg.SourceDir, "directory", "D", def.SourceDir, "Directory with the Go source files")
 	fs.StringVarP(&extractCfg.OutputDir, "output-dir", "p", def.OutputDir, "Directory in which the pot files are stored.")
 	fs.StringVarP(&extractCfg.OutputFile, "output", "o", def.OutputFile, "Write output to specified file")
@@ -53,16 +71,37 @@ func init() {
 	fs.StringArrayP("template-keyword", "k", []string{}, "Sets a keyword that is used within templates to identify translation functions")
 
 	fs.StringVarP(&extractCfg.DefaultDomain, "default-domain", "d", def.DefaultDomain, "Use name.pot for output (instead of messages.pot)")
+	fs.StringArray("package-domain", []string{}, "Default domain for the strings of packages matching a pattern, e.g. \"./internal/admin/...=admin\". Can be repeated, the first matching rule is used")
+	fs.StringArray("package-context", []string{}, "Default context for the strings of packages matching a pattern, e.g. \"./cmd/cli/...=cli\". Can be repeated, the first matching rule is used")
+	fs.StringVar(&extractCfg.OnConflict, "on-conflict", def.OnConflict, "What to do if a message is defined differently in several places: warn, fail or ignore")
 	fs.BoolVar(&extractCfg.WriteNoLocation, "no-location", def.WriteNoLocation, "Do not write '#: filename:line' lines")
+	fs.StringVar(&extractCfg.AddLocation, "add-location", def.AddLocation, "How '#: filename:line' lines are written: full, file (without line numbers) or never")
+	fs.StringVar(&extractCfg.ReferenceBase, "reference-base", def.ReferenceBase, "Directory to which the references are relative (default is the output directory)")
+	fs.StringVar(&extractCfg.ReferenceURL, "reference-url", def.ReferenceURL, "Link to the source code written as extracted comment, e.g. \"https://git.example/blob/{rev}/{path}#L{line}\"")
+	fs.StringVar(&extractCfg.ReferenceRevision, "reference-revision", def.ReferenceRevision, "Value of {rev} in the reference URL (default is the current git commit)")
 
 	fs.IntVarP(&extractCfg.WrapWidth, "width", "w", def.WrapWidth, "Set output page width")
 	fs.BoolVar(&extractCfg.DontWrap, "no-wrap", def.DontWrap, "Do not break long message lines, longer than the output page width, into several lines")
+	fs.BoolVarP(&extractCfg.SortOutput, "sort-output", "s", def.SortOutput, "Generate sorted output, ordered by msgid")
+	fs.BoolVarP(&extractCfg.SortByFile, "sort-by-file", "F", def.SortByFile, "Sort output by file location")
 
 	fs.BoolVar(&extractCfg.OmitHeader, "omit-header", def.OmitHeader, "Don't write header with 'msgid \"\"' entry")
 	fs.StringVar(&extractCfg.CopyrightHolder, "copyright-holder", def.CopyrightHolder, "Set copyright holder in output")
 	fs.StringVar(&extractCfg.PackageName, "package-name", def.PackageName, "Set package name in output")
 	fs.StringVar(&extractCfg.BugsAddress, "msgid-bugs-address", def.BugsAddress, "Set report address for msgid bugs")
+	fs.StringVar(&extractCfg.LanguageTeam, "language-team", def.LanguageTeam, "Set the Language-Team header field")
+	fs.StringVar(&extractCfg.Generator, "generator", def.Generator, "Set the X-Generator header field")
+	fs.StringArray("header-field", []string{}, "Additional header field, e.g. \"X-Crowdin-Project=app\". Can be repeated")
+	fs.StringVar(&extractCfg.HeaderTemplate, "header-template", def.HeaderTemplate, "PO file whose header is used as template for the header, placeholders like {{.PackageName}} are replaced")
+	fs.BoolVar(&extractCfg.KeepHeader, "keep-header", def.KeepHeader, "Copy the header from the existing output file, so manual changes are preserved")
+	fs.StringVar(&extractCfg.CreationDate, "creation-date", def.CreationDate, "How the POT-Creation-Date header is written: now, keep (unchanged if nothing else changed) or omit")
+	fs.StringArray("build", []string{}, "Build configuration to load the packages with, e.g. \"goos=windows goarch=amd64 tags=foo,bar tests\". Can be repeated, the results are merged")
+	fs.StringArrayVar(&extractCfg.Excludes, "exclude", []string{}, "Glob pattern of Go files, template files or directories that are not extracted, e.g. \"*_gen.go\" or \"internal/testutil\". Can be repeated")
+	fs.StringArrayVar(&extractCfg.ExcludedPackages, "exclude-package", []string{}, "Package pattern of packages that are not extracted, e.g. \"./internal/testutil/...\". Can be repeated")
 	fs.StringSliceVarP(&extractCfg.LoadedPackages, "loaded-packages", "l", []string{}, "List of packages divided by comma to search for translations")
+	fs.IntVarP(&extractCfg.Jobs, "jobs", "j", def.Jobs, "Number of extractors that run in parallel (default is the number of CPUs)")
+	fs.Bool("no-cache", false, "Extract all packages again instead of using the results of unchanged packages")
+	fs.StringVar(&extractCfg.CacheDir, "cache-dir", def.CacheDir, "Directory for the extraction cache (default is the user cache directory)")
 }
 
 func initVersionNumber() {
@@ -82,8 +121,8 @@ func initVersionNumber() {
 }
 
 func extractCmdF(cmd *cobra.Command, args []string) {
-	validateExtractConfig(cmd)
 	extractCfg.Args = args
+	validateExtractConfig(cmd)
 
 	extractor := NewExtractor()
 	extractor.extract()
@@ -91,6 +130,10 @@ func extractCmdF(cmd *cobra.Command, args []string) {
 
 func validateExtractConfig(cmd *cobra.Command) {
 	fs := cmd.Flags()
+	if err := applyConfigFile(fs); err != nil {
+		log.Fatalf("Configuration file could not be loaded: %v", err)
+	}
+
 	if keywordPrefix, errP := fs.GetString("template-prefix"); errP != nil {
 		log.WithError(errP).Fatal("Args could not be parsed")
 	} else if keywordPrefix != "" {
@@ -109,13 +152,107 @@ func validateExtractConfig(cmd *cobra.Command) {
 		}
 	}
 
+	if rawBuilds, err := fs.GetStringArray("build"); err != nil {
+		log.WithError(err).Fatal("Args could not be parsed")
+	} else {
+		for _, raw := range rawBuilds {
+			build, errB := config.ParseBuildConfig(raw)
+			if errB != nil {
+				log.WithError(errB).Fatalf("Arg could not be parsed %s", raw)
+			}
+			extractCfg.Builds = append(extractCfg.Builds, build)
+		}
+	}
+
+	for _, name := range []string{"package-domain", "package-context"} {
+		rawRules, err := fs.GetStringArray(name)
+		if err != nil {
+			log.WithError(err).Fatal("Args could not be parsed")
+		}
+		for _, raw := range rawRules {
+			pattern, value, errR := config.ParsePackageRule(raw)
+			if errR != nil {
+				log.WithError(errR).Fatalf("Arg could not be parsed %s", raw)
+			}
+			rule := config.PackageRule{Pattern: pattern}
+			if name == "package-domain" {
+				rule.Domain = value
+			} else {
+				rule.Context = value
+			}
+			extractCfg.PackageRules = append(extractCfg.PackageRules, rule)
+		}
+	}
+
+	if rawFields, err := fs.GetStringArray("header-field"); err != nil {
+		log.WithError(err).Fatal("Args could not be parsed")
+	} else {
+		for _, raw := range rawFields {
+			field, errF := config.ParseHeaderField(raw)
+			if errF != nil {
+				log.WithError(errF).Fatalf("Arg could not be parsed %s", raw)
+			}
+			extractCfg.HeaderFields = append(extractCfg.HeaderFields, field)
+		}
+	}
+
+	if noCache, err := fs.GetBool("no-cache"); err != nil {
+		log.WithError(err).Fatal("Args could not be parsed")
+	} else if noCache {
+		extractCfg.CacheDir = ""
+	} else if extractCfg.CacheDir == "" {
+		if userCacheDir, errC := os.UserCacheDir(); errC == nil {
+			extractCfg.CacheDir = filepath.Join(userCacheDir, "xspreak")
+		} else {
+			log.WithError(errC).Debug("No cache directory available, the cache is disabled")
+		}
+	}
+	extractCfg.Version = Version
+
 	if err := extractCfg.Prepare(); err != nil {
 		log.Fatalf("Configuration could not be processed: %v", err)
 	}
 
+	if strings.Contains(extractCfg.ReferenceURL, "{rev}") && extractCfg.ReferenceRevision == "" {
+		rev, err := gitRevision(extractCfg.SourceDir)
+		if err != nil {
+			log.WithError(err).Fatal("The git revision for the reference URL could not be determined, use --reference-revision")
+		}
+		extractCfg.ReferenceRevision = rev
+	}
+
+	if strings.Contains(extractCfg.ReferenceURL, "{path}") && extractCfg.ReferenceRoot == "" {
+		root, err := gitTopLevel(extractCfg.SourceDir)
+		if err != nil {
+			log.WithError(err).Warn("The git repository root could not be determined, {path} in the reference URL is relative to the source directory")
+		} else {
+			extractCfg.ReferenceRoot = filepath.FromSlash(root)
+		}
+	}
+
 	if extractCfg.IsVerbose {
 		log.SetLevel(log.DebugLevel)
 	}
 
 	log.Debug("Starting execution...")
 }
+
+// gitRevision returns the commit hash of the git repository containing dir.
+func gitRevision(dir string) (string, error) {
+	return gitRevParse(dir, "HEAD")
+}
+
+// gitTopLevel returns the root directory of the git repository containing dir.
+func gitTopLevel(dir string) (string, error) {
+	return gitRevParse(dir, "--show-toplevel")
+}
+
+func gitRevParse(dir, arg string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", arg)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
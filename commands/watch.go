@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mattn/go-zglob"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Extract the strings again whenever a file changes",
+	Long: `Watch extracts the strings and then watches the Go files of the extracted packages and the template files.
+When files change, the strings are extracted again.
+The output files are only rewritten if their content has changed.
+Packages that have not changed are taken from the cache, unless --no-cache is set.`,
+	Run:     watchCmdF,
+	Example: `  xspreak watch -D ./ -p locale/ -t "templates/*.html"`,
+}
+
+func init() {
+	fs := watchCmd.Flags()
+	addExtractFlags(fs)
+	fs.Duration("debounce", 300*time.Millisecond, "Time to wait for further changes before extracting again")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+func watchCmdF(cmd *cobra.Command, args []string) {
+	extractCfg.Args = args
+	validateExtractConfig(cmd)
+
+	debounce, err := cmd.Flags().GetDuration("debounce")
+	if err != nil {
+		log.WithError(err).Fatal("Args could not be parsed")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	extractor := NewExtractor()
+	extractor.skipUnchanged = true
+
+	w := &watcher{
+		cfg:       extractCfg,
+		extractor: extractor,
+		debounce:  debounce,
+		dirs:      make(map[string]bool),
+		log:       log.WithField("service", "watcher"),
+	}
+	if errW := w.run(ctx); errW != nil {
+		log.WithError(errW).Fatal("Watching failed")
+	}
+}
+
+// watcher runs the extraction whenever a Go file or a template file changes.
+type watcher struct {
+	cfg       *config.Config
+	extractor *Extractor
+	debounce  time.Duration
+
+	fsw *fsnotify.Watcher
+	// dirs contains the watched directories.
+	dirs map[string]bool
+	log  *log.Entry
+}
+
+func (w *watcher) run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+	w.fsw = fsw
+
+	w.extract(ctx)
+
+	// Changes are collected until no further changes occur for the debounce time
+	var timer *time.Timer
+	var fire <-chan time.Time
+	// workspaceChanged is set if a go.work file has changed since the last extraction
+	var workspaceChanged bool
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if !w.isRelevant(event) {
+				continue
+			}
+
+			w.log.Debugf("Change detected: %s", event)
+			if filepath.Base(event.Name) == "go.work" {
+				workspaceChanged = true
+			}
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				timer.Reset(w.debounce)
+			}
+			fire = timer.C
+		case errW, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.log.WithError(errW).Warn("Watching error")
+		case <-fire:
+			fire = nil
+			if workspaceChanged {
+				workspaceChanged = false
+				w.extractor.resetWorkspace()
+			}
+			w.extract(ctx)
+		}
+	}
+}
+
+// extract runs the extraction and updates the watched directories afterward,
+// so that new packages and template files are watched.
+func (w *watcher) extract(ctx context.Context) {
+	extractCtx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+	defer cancel()
+
+	if err := w.extractor.run(extractCtx); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		w.log.WithError(err).Error("Extraction failed, waiting for further changes")
+	}
+
+	if err := w.updateDirs(extractCtx); err != nil {
+		w.log.WithError(err).Warn("Watched files could not be determined")
+	}
+}
+
+func (w *watcher) updateDirs(ctx context.Context) error {
+	files, err := w.extractor.sourceFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	dirs := map[string]bool{w.cfg.SourceDir: true}
+	for _, file := range files {
+		dirs[filepath.Dir(file)] = true
+	}
+	for _, pattern := range w.cfg.TemplatePatterns {
+		dirs[patternBase(w.absPattern(pattern))] = true
+	}
+
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			_ = w.fsw.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if errA := w.fsw.Add(dir); errA != nil {
+			w.log.WithError(errA).Debugf("Directory %s cannot be watched", dir)
+			continue
+		}
+		w.dirs[dir] = true
+	}
+
+	w.log.Debugf("Watching %d directories", len(w.dirs))
+	return nil
+}
+
+// isRelevant reports whether an event requires a new extraction.
+// Output files never trigger an extraction, unless they match a template pattern.
+func (w *watcher) isRelevant(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	// New directories are watched immediately, since they may contain new packages or templates
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if errA := w.fsw.Add(event.Name); errA == nil {
+				w.dirs[event.Name] = true
+			}
+			return false
+		}
+	}
+
+	switch filepath.Base(event.Name) {
+	case "go.mod", "go.sum", "go.work":
+		return true
+	}
+
+	if strings.HasSuffix(event.Name, ".go") {
+		return true
+	}
+
+	for _, pattern := range w.cfg.TemplatePatterns {
+		if matched, _ := zglob.Match(w.absPattern(pattern), event.Name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *watcher) absPattern(pattern string) string {
+	if filepath.IsAbs(pattern) {
+		return pattern
+	}
+	return filepath.Join(w.cfg.CurrentDir, pattern)
+}
+
+// patternBase returns the directory of a glob pattern up to the first wildcard.
+func patternBase(pattern string) string {
+	idx := strings.IndexAny(pattern, "*?[{")
+	if idx < 0 {
+		return filepath.Dir(pattern)
+	}
+	return filepath.Dir(pattern[:idx])
+}
@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+func newTestWatcher(t *testing.T, dir string, patterns ...string) *watcher {
+	t.Helper()
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	cfg.OutputDir = filepath.Join(dir, "locale")
+	cfg.TemplatePatterns = patterns
+	cfg.CacheDir = ""
+	require.NoError(t, cfg.Prepare())
+	cfg.CurrentDir = dir
+
+	extractCfg = cfg
+	t.Cleanup(func() { extractCfg = config.NewDefault() })
+
+	extractor := NewExtractor()
+	extractor.skipUnchanged = true
+	return &watcher{
+		cfg:       cfg,
+		extractor: extractor,
+		debounce:  50 * time.Millisecond,
+		dirs:      make(map[string]bool),
+		log:       log.WithField("service", "watcher"),
+	}
+}
+
+func TestWatcherIsRelevant(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWatcher(t, dir, "templates/**/*.html", "locale/*.tmpl")
+
+	fsw, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = fsw.Close() })
+	w.fsw = fsw
+
+	newDir := filepath.Join(dir, "pkg")
+	require.NoError(t, os.Mkdir(newDir, 0o755))
+
+	tests := []struct {
+		name     string
+		op       fsnotify.Op
+		relevant bool
+	}{
+		{"main.go", fsnotify.Write, true},
+		{"main.go", fsnotify.Chmod, false},
+		{"main.go", fsnotify.Remove, true},
+		{"go.mod", fsnotify.Write, true},
+		{"go.sum", fsnotify.Write, true},
+		{"go.work", fsnotify.Create, true},
+		{"README.md", fsnotify.Write, false},
+		{"templates/admin/index.html", fsnotify.Write, true},
+		{"templates/admin/index.txt", fsnotify.Write, false},
+		{"templates/index.html", fsnotify.Chmod, false},
+		{"locale/messages.pot", fsnotify.Write, false},
+		{"locale/messages.json", fsnotify.Create, false},
+		{"locale/inline.tmpl", fsnotify.Write, true},
+		{"pkg", fsnotify.Create, false},
+	}
+
+	for _, tt := range tests {
+		event := fsnotify.Event{Name: filepath.Join(dir, filepath.FromSlash(tt.name)), Op: tt.op}
+		assert.Equal(t, tt.relevant, w.isRelevant(event), "%s %s", tt.op, tt.name)
+	}
+
+	assert.True(t, w.dirs[newDir], "new directories must be watched")
+}
+
+func TestPatternBase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"/app/templates/*.html", "/app/templates"},
+		{"/app/templates/**/*.html", "/app/templates"},
+		{"/app/templates/index.html", "/app/templates"},
+		{"/app/templates/page-?.html", "/app/templates"},
+		{"/app/templates/[ab]/*.html", "/app/templates"},
+		{"/app/{web,mail}/*.html", "/app"},
+		{"/app/tmpl*/index.html", "/app"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, filepath.FromSlash(tt.want), patternBase(filepath.FromSlash(tt.pattern)), tt.pattern)
+	}
+}
+
+func writeWatchModule(t *testing.T, dir string, msgIDs ...string) {
+	t.Helper()
+	var src strings.Builder
+	src.WriteString("package main\n\nimport \"github.com/vorlif/spreak/localize\"\n\n")
+	for i, msgID := range msgIDs {
+		fmt.Fprintf(&src, "var msg%d localize.Singular = %q\n", i, msgID)
+	}
+	src.WriteString("\nfunc main() {}\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src.String()), 0o644))
+}
+
+func TestWatcherRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the packages are loaded several times")
+	}
+
+	dir := t.TempDir()
+	goMod := "module example.com/watch\n\ngo 1.24.0\n\nrequire github.com/vorlif/spreak v1.0.0\n\nrequire golang.org/x/text v0.29.0 // indirect\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644))
+	goSum, err := os.ReadFile(filepath.Join("..", "testdata", "project", "go.sum"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644))
+	writeWatchModule(t, dir, "Hello")
+
+	level := log.GetLevel()
+	log.SetLevel(log.DebugLevel)
+	hook := test.NewGlobal()
+	t.Cleanup(func() {
+		hook.Reset()
+		log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+		log.SetLevel(level)
+	})
+
+	countMessages := func(prefix string) int {
+		n := 0
+		for _, entry := range hook.AllEntries() {
+			if strings.HasPrefix(entry.Message, prefix) {
+				n++
+			}
+		}
+		return n
+	}
+	written := func() int { return countMessages("File written") }
+	unchanged := func() int { return countMessages("File unchanged") }
+
+	w := newTestWatcher(t, dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	outputFile := filepath.Join(dir, "locale", "messages.pot")
+	require.Eventually(t, func() bool { return written() == 1 }, 30*time.Second, 20*time.Millisecond)
+	// The files are watched after the first extraction
+	require.Eventually(t, func() bool { return countMessages("Watching ") == 1 }, 30*time.Second, 20*time.Millisecond)
+
+	t.Run("changed messages are written once", func(t *testing.T) {
+		writeWatchModule(t, dir, "Hello", "World")
+		require.Eventually(t, func() bool { return written() == 2 }, 30*time.Second, 20*time.Millisecond)
+		assert.Never(t, func() bool { return written() > 2 }, 500*time.Millisecond, 20*time.Millisecond)
+
+		content, errR := os.ReadFile(outputFile)
+		require.NoError(t, errR)
+		assert.Contains(t, string(content), `msgid "World"`)
+	})
+
+	t.Run("unchanged messages are not written", func(t *testing.T) {
+		before := unchanged()
+		writeWatchModule(t, dir, "Hello", "World")
+		require.Eventually(t, func() bool { return unchanged() > before }, 30*time.Second, 20*time.Millisecond)
+		assert.Never(t, func() bool { return written() > 2 }, 500*time.Millisecond, 20*time.Millisecond)
+	})
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BuildConfig describes a build configuration for which the packages are loaded.
+// Files excluded by build constraints are only found if a matching build configuration is used.
+type BuildConfig struct {
+	// Tags are the additional build tags.
+	Tags []string
+	// GOOS and GOARCH override the target system. Empty values use the default of the go command.
+	GOOS   string
+	GOARCH string
+	// Tests includes the _test.go files of the packages.
+	Tests bool
+}
+
+// ParseBuildConfig parses a build configuration of the form "goos=windows goarch=amd64 tags=foo,bar tests".
+// All fields are optional.
+func ParseBuildConfig(raw string) (BuildConfig, error) {
+	var b BuildConfig
+	for _, field := range strings.Fields(raw) {
+		key, value, hasValue := strings.Cut(field, "=")
+		switch strings.ToLower(key) {
+		case "goos":
+			b.GOOS = value
+		case "goarch":
+			b.GOARCH = value
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					b.Tags = append(b.Tags, tag)
+				}
+			}
+			continue
+		case "tests":
+			if hasValue {
+				return b, fmt.Errorf("build configuration %q: tests does not take a value", raw)
+			}
+			b.Tests = true
+			continue
+		default:
+			return b, fmt.Errorf("build configuration %q: unknown field %q", raw, key)
+		}
+
+		if value == "" {
+			return b, fmt.Errorf("build configuration %q: %s requires a value", raw, key)
+		}
+	}
+
+	return b, nil
+}
+
+// String returns the build configuration in the form accepted by ParseBuildConfig.
+func (b BuildConfig) String() string {
+	var fields []string
+	if b.GOOS != "" {
+		fields = append(fields, "goos="+b.GOOS)
+	}
+	if b.GOARCH != "" {
+		fields = append(fields, "goarch="+b.GOARCH)
+	}
+	if len(b.Tags) > 0 {
+		fields = append(fields, "tags="+strings.Join(b.Tags, ","))
+	}
+	if b.Tests {
+		fields = append(fields, "tests")
+	}
+
+	if len(fields) == 0 {
+		return "default"
+	}
+	return strings.Join(fields, " ")
+}
+
+// Env returns the environment of the go command for the build configuration.
+func (b BuildConfig) Env() []string {
+	env := os.Environ()
+	if b.GOOS != "" {
+		env = append(env, "GOOS="+b.GOOS)
+	}
+	if b.GOARCH != "" {
+		env = append(env, "GOARCH="+b.GOARCH)
+	}
+	return env
+}
+
+// BuildFlags returns the flags of the go command for the build configuration.
+func (b BuildConfig) BuildFlags() []string {
+	if len(b.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(b.Tags, ",")}
+}
+
+// BuildConfigs returns a copy of the configuration for each build configuration in Builds.
+// Without build configurations only the configuration itself is returned.
+func (c *Config) BuildConfigs() []*Config {
+	if len(c.Builds) == 0 {
+		return []*Config{c}
+	}
+
+	configs := make([]*Config, 0, len(c.Builds))
+	for _, b := range c.Builds {
+		buildCfg := *c
+		buildCfg.Build = b
+		configs = append(configs, &buildCfg)
+	}
+	return configs
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBuildConfig(t *testing.T) {
+	b, err := ParseBuildConfig("goos=windows goarch=arm64 tags=foo,bar tests")
+	require.NoError(t, err)
+	assert.Equal(t, BuildConfig{GOOS: "windows", GOARCH: "arm64", Tags: []string{"foo", "bar"}, Tests: true}, b)
+	assert.Equal(t, "goos=windows goarch=arm64 tags=foo,bar tests", b.String())
+	assert.Equal(t, []string{"-tags=foo,bar"}, b.BuildFlags())
+
+	b, err = ParseBuildConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, "default", b.String())
+	assert.Nil(t, b.BuildFlags())
+
+	for _, raw := range []string{"os=linux", "goos=", "tests=true"} {
+		_, err = ParseBuildConfig(raw)
+		assert.Error(t, err, raw)
+	}
+}
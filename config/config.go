@@ -5,15 +5,54 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
+	"golang.org/x/text/language"
+
 	"github.com/vorlif/xspreak/tmpl"
 )
 
 const (
-	ExtractFormatPot  = "pot"
-	ExtractFormatJSON = "json"
+	ExtractFormatPot   = "pot"
+	ExtractFormatJSON  = "json"
+	ExtractFormatXLIFF = "xliff"
+	ExtractFormatCSV   = "csv"
+	ExtractFormatTSV   = "tsv"
+)
+
+// Supported XLIFF versions.
+const (
+	XLIFFVersion12 = "1.2"
+	XLIFFVersion20 = "2.0"
+)
+
+// Layouts of JSON files.
+const (
+	JSONLayoutFlat   = "flat"
+	JSONLayoutNested = "nested"
+)
+
+// Handling of conflicting definitions of the same message.
+const (
+	ConflictWarn   = "warn"
+	ConflictFail   = "fail"
+	ConflictIgnore = "ignore"
+)
+
+// Handling of the POT-Creation-Date header.
+const (
+	CreationDateNow  = "now"
+	CreationDateKeep = "keep"
+	CreationDateOmit = "omit"
+)
+
+// Location reference styles.
+const (
+	LocationFull  = "full"
+	LocationFile  = "file"
+	LocationNever = "never"
 )
 
 type Config struct {
@@ -37,21 +76,102 @@ type Config struct {
 	WrapWidth       int
 	DontWrap        bool
 
+	// AddLocation determines how references are written.
+	// Possible values: "full", "file", "never". WriteNoLocation is the same as "never".
+	AddLocation string
+	// ReferenceBase is the directory to which the references are relative.
+	// If empty, the references are relative to the output directory.
+	ReferenceBase string
+	// ReferenceURL is a template for a link to the source code that is written as extracted comment,
+	// e.g. "https://git.example/blob/{rev}/{path}#L{line}".
+	ReferenceURL string
+	// ReferenceRevision is the value of {rev} in the ReferenceURL.
+	ReferenceRevision string
+	// ReferenceRoot is the directory to which {path} in the ReferenceURL is relative, the root of the repository.
+	// If empty, the source directory is used.
+	ReferenceRoot string
+
+	// SortOutput sorts the messages by msgid, SortByFile by their references.
+	// Only one of them can be set.
+	SortOutput bool
+	SortByFile bool
+
 	OmitHeader      bool
 	CopyrightHolder string
 	PackageName     string
 	BugsAddress     string
+	// CreationDate determines how the POT-Creation-Date header is written.
+	// Possible values: "now", "keep", "omit"
+	CreationDate string
+	// LanguageTeam and Generator are the values of the Language-Team and X-Generator header fields.
+	// Empty values keep the value of the header.
+	LanguageTeam string
+	Generator    string
+	// HeaderFields are additional X- fields of the header.
+	HeaderFields []HeaderField
+	// HeaderTemplate is the path to a PO file whose header is used instead of the placeholder header.
+	// The file is executed as a text/template before it is parsed.
+	HeaderTemplate string
+	// KeepHeader copies the header from an existing output file, if there is one.
+	KeepHeader bool
 
 	Args []string
 
 	LoadedPackages []string
 
+	// Excludes are glob patterns of files and directories that are not extracted.
+	// They are applied to the Go files and the template files.
+	Excludes []string
+	// ExcludedPackages are package patterns of packages that are not extracted.
+	ExcludedPackages []string
+
+	// OnConflict determines how conflicting definitions of the same message are handled.
+	// Possible values: "warn", "fail", "ignore"
+	OnConflict string
+
+	// PackageRules assign a default domain or context to the strings of packages.
+	PackageRules []PackageRule
+
+	// Builds are the build configurations for which the packages are loaded.
+	// The results of all build configurations are merged.
+	Builds []BuildConfig
+	// Build is the build configuration used by the loader, see BuildConfigs.
+	Build BuildConfig
+
+	// Targets are the named outputs of the extraction.
+	// Without targets, all strings are written to the output of the configuration.
+	Targets []Target
+
 	Timeout time.Duration
 
+	// Jobs is the number of extractors that run in parallel.
+	// A value less than one uses the number of available CPUs.
+	Jobs int
+
+	// CacheDir is the directory in which the results of unchanged packages are cached.
+	// An empty value disables the cache.
+	CacheDir string
+
+	// Version is the version of xspreak. It is part of the cache keys.
+	Version string
+
 	// ExtractFormat is the format of the output file.
-	// Possible values: "po", "pot", "json"
+	// Possible values: "po", "pot", "json", "xliff"
 	ExtractFormat     string
 	TmplIsMonolingual bool
+	// JSONMeta writes the comments, references and flags of a JSON file to a *.meta.json file.
+	JSONMeta bool
+	// JSONLayout is the layout of JSON files, "flat" or "nested".
+	// In the nested layout the keys are split at JSONSeparator into nested objects.
+	JSONLayout    string
+	JSONSeparator string
+	// XLIFFVersion is the version of XLIFF files, "1.2" or "2.0".
+	XLIFFVersion string
+	// SourceLanguage is the language of the extracted strings.
+	SourceLanguage string
+	// TargetLanguage is the language into which the strings are translated.
+	// If set, XLIFF and CSV files contain the plural forms of this language.
+	TargetLanguage string
 }
 
 func NewDefault() *Config {
@@ -68,15 +188,23 @@ func NewDefault() *Config {
 		WriteNoLocation: false,
 		WrapWidth:       80,
 		DontWrap:        false,
+		AddLocation:     LocationFull,
 
 		OmitHeader:      false,
 		CopyrightHolder: "THE PACKAGE'S COPYRIGHT HOLDER",
 		PackageName:     "PACKAGE VERSION",
 		BugsAddress:     "",
+		CreationDate:    CreationDateNow,
 
 		Timeout: 15 * time.Minute,
 
-		ExtractFormat: ExtractFormatPot,
+		OnConflict: ConflictWarn,
+
+		ExtractFormat:  ExtractFormatPot,
+		JSONLayout:     JSONLayoutFlat,
+		JSONSeparator:  ".",
+		XLIFFVersion:   XLIFFVersion20,
+		SourceLanguage: "en",
 	}
 }
 
@@ -91,6 +219,10 @@ func (c *Config) Prepare() error {
 		return errors.New("the value for Timeout must be at least one minute")
 	}
 
+	if c.Jobs < 1 {
+		c.Jobs = runtime.GOMAXPROCS(0)
+	}
+
 	currentDir, errC := os.Getwd()
 	if errC != nil {
 		return errC
@@ -119,17 +251,109 @@ func (c *Config) Prepare() error {
 		return err
 	}
 
+	if c.ReferenceBase != "" {
+		c.ReferenceBase, err = filepath.Abs(c.ReferenceBase)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.ReferenceRoot != "" {
+		c.ReferenceRoot, err = filepath.Abs(c.ReferenceRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.HeaderTemplate != "" {
+		c.HeaderTemplate, err = filepath.Abs(c.HeaderTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.CacheDir != "" {
+		c.CacheDir, err = filepath.Abs(c.CacheDir)
+		if err != nil {
+			return err
+		}
+	}
+
 	if c.DontWrap {
 		c.WrapWidth = -1
 	}
 
-	switch c.ExtractFormat {
-	case "po":
-		c.ExtractFormat = ExtractFormatPot
-	case ExtractFormatJSON, ExtractFormatPot:
+	if c.ExtractFormat, err = normalizeFormat(c.ExtractFormat); err != nil {
+		return err
+	}
+
+	switch c.OnConflict {
+	case "":
+		c.OnConflict = ConflictWarn
+	case ConflictWarn, ConflictFail, ConflictIgnore:
+		break
+	default:
+		return fmt.Errorf("invalid conflict handling %q, use %q, %q or %q", c.OnConflict, ConflictWarn, ConflictFail, ConflictIgnore)
+	}
+
+	switch c.JSONLayout {
+	case "":
+		c.JSONLayout = JSONLayoutFlat
+	case JSONLayoutFlat:
 		break
+	case JSONLayoutNested:
+		if c.JSONSeparator == "" {
+			return errors.New("the nested JSON layout requires a separator")
+		}
 	default:
-		return fmt.Errorf("only the JSON and pot format is supported, you want %v", c.ExtractFormat)
+		return fmt.Errorf("invalid JSON layout %q, use %q or %q", c.JSONLayout, JSONLayoutFlat, JSONLayoutNested)
+	}
+
+	switch c.XLIFFVersion {
+	case "":
+		c.XLIFFVersion = XLIFFVersion20
+	case XLIFFVersion12, XLIFFVersion20:
+		break
+	default:
+		return fmt.Errorf("invalid XLIFF version %q, use %q or %q", c.XLIFFVersion, XLIFFVersion12, XLIFFVersion20)
+	}
+
+	for _, lang := range []string{c.SourceLanguage, c.TargetLanguage} {
+		if lang == "" {
+			continue
+		}
+		if _, errL := language.Parse(lang); errL != nil {
+			return fmt.Errorf("invalid language %q: %w", lang, errL)
+		}
+	}
+
+	switch c.AddLocation {
+	case "":
+		c.AddLocation = LocationFull
+	case LocationFull, LocationFile, LocationNever:
+		break
+	default:
+		return fmt.Errorf("invalid location style %q, use %q, %q or %q", c.AddLocation, LocationFull, LocationFile, LocationNever)
+	}
+	if c.WriteNoLocation {
+		c.AddLocation = LocationNever
+	}
+
+	if c.SortOutput && c.SortByFile {
+		return errors.New("the output can be sorted either by msgid or by file, not both")
+	}
+
+	switch c.CreationDate {
+	case "":
+		c.CreationDate = CreationDateNow
+	case CreationDateNow, CreationDateKeep, CreationDateOmit:
+		break
+	default:
+		return fmt.Errorf("invalid creation date handling %q, use %q, %q or %q", c.CreationDate, CreationDateNow, CreationDateKeep, CreationDateOmit)
+	}
+
+	if err = c.prepareTargets(); err != nil {
+		return err
 	}
 
 	if len(c.TemplatePatterns) > 0 && len(c.Keywords) == 0 {
@@ -138,3 +362,36 @@ func (c *Config) Prepare() error {
 
 	return nil
 }
+
+func normalizeFormat(format string) (string, error) {
+	switch format {
+	case "po":
+		return ExtractFormatPot, nil
+	case "xlf":
+		return ExtractFormatXLIFF, nil
+	case ExtractFormatJSON, ExtractFormatPot, ExtractFormatXLIFF, ExtractFormatCSV, ExtractFormatTSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("only the JSON, pot, XLIFF, CSV and TSV format is supported, you want %v", format)
+	}
+}
+
+// PackageRule assigns a domain or a context to all strings of the packages matching Pattern
+// that do not have a domain or context yet.
+// Pattern is a package pattern like "./internal/admin/..." or "example.com/app/cmd/cli".
+type PackageRule struct {
+	Pattern string
+	Domain  string
+	Context string
+}
+
+// ParsePackageRule parses a rule of the form "pattern=value".
+func ParsePackageRule(raw string) (pattern, value string, err error) {
+	pattern, value, found := strings.Cut(raw, "=")
+	pattern = strings.TrimSpace(pattern)
+	value = strings.TrimSpace(value)
+	if !found || pattern == "" || value == "" {
+		return "", "", fmt.Errorf("invalid package rule %q, expected the form pattern=value", raw)
+	}
+	return pattern, value, nil
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the names of the configuration files searched for in the source directory, in this order.
+var FileNames = []string{
+	"xspreak.yaml",
+	"xspreak.yml",
+	".xspreak.yaml",
+	".xspreak.yml",
+	"xspreak.toml",
+	".xspreak.toml",
+}
+
+// FindFile returns the path of the configuration file in dir.
+// If there is no configuration file, an empty string is returned.
+func FindFile(dir string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// FileValue is a value of a configuration file.
+// Lists are returned as slices, all other values as strings.
+type FileValue struct {
+	Key    string
+	Value  string
+	Values []string
+	IsList bool
+}
+
+// File is the content of a configuration file.
+type File struct {
+	// Values are the flat values, sorted by key.
+	Values []FileValue
+	// Targets are the targets of the "targets" key.
+	Targets []Target
+}
+
+// targetKeys maps the keys of a target in a configuration file to the setters of the target.
+var targetKeys = map[string]func(t *Target, values []string, isList bool) error{
+	"name":               singleValue(func(t *Target, v string) { t.Name = v }),
+	"format":             singleValue(func(t *Target, v string) { t.ExtractFormat = v }),
+	"output-dir":         singleValue(func(t *Target, v string) { t.OutputDir = v }),
+	"output":             singleValue(func(t *Target, v string) { t.OutputFile = v }),
+	"default-domain":     singleValue(func(t *Target, v string) { t.DefaultDomain = v }),
+	"packages":           func(t *Target, v []string, _ bool) error { t.Packages = v; return nil },
+	"template-directory": func(t *Target, v []string, _ bool) error { t.TemplatePatterns = v; return nil },
+}
+
+func singleValue(set func(t *Target, v string)) func(t *Target, values []string, isList bool) error {
+	return func(t *Target, values []string, isList bool) error {
+		if isList {
+			return errors.New("a single value is expected, got a list")
+		}
+		set(t, values[0])
+		return nil
+	}
+}
+
+// ReadFile reads a YAML or TOML configuration file, depending on its extension.
+// Apart from the targets, the file must be a flat mapping of keys to scalar values or lists of scalar values.
+// Relative paths of the targets are resolved relative to the directory of the file.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		if errD := dec.Decode(&raw); errD != nil && !errors.Is(errD, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, errD)
+		}
+	case ".toml":
+		if _, errD := toml.Decode(string(data), &raw); errD != nil {
+			return nil, fmt.Errorf("%s: %w", path, errD)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported configuration format %q, use YAML or TOML", path, ext)
+	}
+
+	file := &File{Values: make([]FileValue, 0, len(raw))}
+	for key, value := range raw {
+		if key == "targets" {
+			file.Targets, err = readTargets(filepath.Dir(path), value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			continue
+		}
+
+		fv, errV := readValue(key, value)
+		if errV != nil {
+			return nil, fmt.Errorf("%s: %w", path, errV)
+		}
+		file.Values = append(file.Values, fv)
+	}
+
+	sort.Slice(file.Values, func(i, j int) bool { return file.Values[i].Key < file.Values[j].Key })
+	return file, nil
+}
+
+func readValue(key string, value any) (FileValue, error) {
+	fv := FileValue{Key: key}
+	switch v := value.(type) {
+	case []any:
+		fv.IsList = true
+		fv.Values = make([]string, 0, len(v))
+		for _, elem := range v {
+			s, ok := scalarString(elem)
+			if !ok {
+				return fv, fmt.Errorf("key %q: lists may only contain strings, numbers or booleans", key)
+			}
+			fv.Values = append(fv.Values, s)
+		}
+	default:
+		s, ok := scalarString(v)
+		if !ok {
+			return fv, fmt.Errorf("key %q: value must be a string, number, boolean or list", key)
+		}
+		fv.Value = s
+	}
+
+	return fv, nil
+}
+
+func readTargets(baseDir string, value any) ([]Target, error) {
+	var rawTargets []map[string]any
+	switch v := value.(type) {
+	case []map[string]any:
+		rawTargets = v
+	case []any:
+		for _, elem := range v {
+			m, ok := elem.(map[string]any)
+			if !ok {
+				return nil, errors.New("key \"targets\": each target must be a mapping")
+			}
+			rawTargets = append(rawTargets, m)
+		}
+	default:
+		return nil, errors.New("key \"targets\": a list of targets is expected")
+	}
+
+	targets := make([]Target, 0, len(rawTargets))
+	for i, rawTarget := range rawTargets {
+		var t Target
+		for key, value := range rawTarget {
+			set, ok := targetKeys[key]
+			if !ok {
+				return nil, fmt.Errorf("target %d: unknown key %q", i+1, key)
+			}
+
+			fv, err := readValue(key, value)
+			if err != nil {
+				return nil, fmt.Errorf("target %d: %w", i+1, err)
+			}
+
+			values := fv.Values
+			if !fv.IsList {
+				values = []string{fv.Value}
+			}
+			if err = set(&t, values, fv.IsList); err != nil {
+				return nil, fmt.Errorf("target %d: key %q: %w", i+1, key, err)
+			}
+		}
+
+		t.OutputDir = resolvePath(baseDir, t.OutputDir)
+		t.OutputFile = resolvePath(baseDir, t.OutputFile)
+		for j, pattern := range t.TemplatePatterns {
+			t.TemplatePatterns[j] = resolvePath(baseDir, pattern)
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, nil
+}
+
+func resolvePath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+func scalarString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
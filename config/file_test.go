@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.Empty(t, FindFile(dir))
+
+	yamlFile := filepath.Join(dir, "xspreak.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte("extract-errors: true\nwidth: 100\ntemplate-keyword:\n  - i18n.Tr:1\n  - i18n.Trn:1,2\n"), 0o600))
+	tomlFile := filepath.Join(dir, ".xspreak.toml")
+	require.NoError(t, os.WriteFile(tomlFile, []byte("extract-errors = true\nwidth = 100\ntemplate-keyword = [\"i18n.Tr:1\", \"i18n.Trn:1,2\"]\n"), 0o600))
+
+	assert.Equal(t, yamlFile, FindFile(dir))
+
+	want := []FileValue{
+		{Key: "extract-errors", Value: "true"},
+		{Key: "template-keyword", Values: []string{"i18n.Tr:1", "i18n.Trn:1,2"}, IsList: true},
+		{Key: "width", Value: "100"},
+	}
+	for _, path := range []string{yamlFile, tomlFile} {
+		file, err := ReadFile(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, file.Values, path)
+	}
+
+	nested := filepath.Join(dir, "nested.yaml")
+	require.NoError(t, os.WriteFile(nested, []byte("header:\n  package-name: test\n"), 0o600))
+	_, err := ReadFile(nested)
+	assert.ErrorContains(t, err, `key "header"`)
+
+	empty := filepath.Join(dir, "empty.yml")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+	file, err := ReadFile(empty)
+	require.NoError(t, err)
+	assert.Empty(t, file.Values)
+	assert.Empty(t, file.Targets)
+}
+
+func TestReadFileTargets(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "xspreak.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`
+targets:
+  - name: server
+    packages: [./cmd/server/...]
+    output: locale/server.pot
+  - name: web
+    template-directory: "web/**/*.html"
+    format: json
+`), 0o600))
+	tomlFile := filepath.Join(dir, "xspreak.toml")
+	require.NoError(t, os.WriteFile(tomlFile, []byte(`
+[[targets]]
+name = "server"
+packages = ["./cmd/server/..."]
+output = "locale/server.pot"
+
+[[targets]]
+name = "web"
+template-directory = "web/**/*.html"
+format = "json"
+`), 0o600))
+
+	want := []Target{
+		{Name: "server", Packages: []string{"./cmd/server/..."}, OutputFile: filepath.Join(dir, "locale", "server.pot")},
+		{Name: "web", TemplatePatterns: []string{filepath.Join(dir, "web/**/*.html")}, ExtractFormat: "json"},
+	}
+	for _, path := range []string{yamlFile, tomlFile} {
+		file, err := ReadFile(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, file.Targets, path)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("targets:\n  - name: a\n    package: ./...\n"), 0o600))
+	_, err := ReadFile(invalid)
+	assert.ErrorContains(t, err, `unknown key "package"`)
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HeaderField is an additional field of the POT header, e.g. "X-Crowdin-Project: app".
+type HeaderField struct {
+	Name  string
+	Value string
+}
+
+// ParseHeaderField parses a header field of the form "X-Name=value".
+// Only fields starting with "X-" can be added, the standard fields have their own options.
+func ParseHeaderField(raw string) (HeaderField, error) {
+	name, value, found := strings.Cut(raw, "=")
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	if !found || name == "" {
+		return HeaderField{}, fmt.Errorf("invalid header field %q, expected the form X-Name=value", raw)
+	}
+
+	if len(name) < 3 || !strings.EqualFold(name[:2], "X-") {
+		return HeaderField{}, fmt.Errorf("invalid header field %q, the name must start with X-", raw)
+	}
+
+	if strings.ContainsAny(name, ": \t\n") || strings.Contains(value, "\n") {
+		return HeaderField{}, fmt.Errorf("invalid header field %q, the name must not contain colons or spaces", raw)
+	}
+
+	return HeaderField{Name: name, Value: value}, nil
+}
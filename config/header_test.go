@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaderField(t *testing.T) {
+	field, err := ParseHeaderField("X-Crowdin-Project = app")
+	require.NoError(t, err)
+	assert.Equal(t, HeaderField{Name: "X-Crowdin-Project", Value: "app"}, field)
+
+	field, err = ParseHeaderField("x-empty=")
+	require.NoError(t, err)
+	assert.Equal(t, HeaderField{Name: "x-empty"}, field)
+
+	for _, raw := range []string{"", "X-Missing", "=value", "Language=de", "X-=value", "X-A B=value", "X-A:B=value"} {
+		_, err = ParseHeaderField(raw)
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Target is a named output of an extraction.
+// All targets of a run share the loaded packages, each target only contains the strings of its
+// packages and templates.
+type Target struct {
+	Name string
+	// Packages are the package patterns of the target, e.g. "./cmd/server/...".
+	// The packages imported by these packages within the project are part of the target as well.
+	Packages []string
+	// TemplatePatterns are the glob patterns of the template files of the target.
+	TemplatePatterns []string
+
+	ExtractFormat string
+	OutputDir     string
+	OutputFile    string
+	DefaultDomain string
+}
+
+// prepareTargets validates the targets and fills in the missing values from the configuration.
+// The packages and template patterns of all targets are loaded together.
+func (c *Config) prepareTargets() error {
+	if len(c.Targets) == 0 {
+		return nil
+	}
+
+	if len(c.Args) > 0 {
+		return errors.New("package arguments cannot be combined with targets")
+	}
+	if len(c.TemplatePatterns) > 0 {
+		return errors.New("template patterns cannot be combined with targets, set them in the targets")
+	}
+
+	names := make(map[string]bool, len(c.Targets))
+	var args, patterns []string
+	for i := range c.Targets {
+		t := &c.Targets[i]
+		t.Name = strings.TrimSpace(t.Name)
+		if t.Name == "" {
+			return fmt.Errorf("target %d has no name", i+1)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("target %q is defined more than once", t.Name)
+		}
+		names[t.Name] = true
+
+		if len(t.Packages) == 0 && len(t.TemplatePatterns) == 0 {
+			return fmt.Errorf("target %q has neither packages nor template patterns", t.Name)
+		}
+
+		if err := c.prepareTarget(t); err != nil {
+			return fmt.Errorf("target %q: %w", t.Name, err)
+		}
+
+		args = appendUnique(args, t.Packages...)
+		patterns = appendUnique(patterns, t.TemplatePatterns...)
+	}
+
+	c.Args = args
+	c.TemplatePatterns = patterns
+	return nil
+}
+
+func (c *Config) prepareTarget(t *Target) error {
+	var err error
+	if t.ExtractFormat == "" {
+		t.ExtractFormat = c.ExtractFormat
+	} else if t.ExtractFormat, err = normalizeFormat(t.ExtractFormat); err != nil {
+		return err
+	}
+
+	t.DefaultDomain = strings.TrimSpace(t.DefaultDomain)
+	if t.DefaultDomain == "" {
+		t.DefaultDomain = c.DefaultDomain
+	}
+
+	if t.OutputDir == "" {
+		t.OutputDir = c.OutputDir
+	}
+	if t.OutputFile != "" {
+		t.OutputDir = filepath.Dir(t.OutputFile)
+		t.OutputFile = filepath.Base(t.OutputFile)
+	} else {
+		t.OutputFile = fmt.Sprintf("%s.%s", t.DefaultDomain, t.ExtractFormat)
+	}
+
+	t.OutputDir, err = filepath.Abs(t.OutputDir)
+	return err
+}
+
+// TargetConfig returns a copy of the configuration with the output settings of the target.
+func (c *Config) TargetConfig(t Target) *Config {
+	targetCfg := *c
+	targetCfg.Targets = nil
+	targetCfg.Args = t.Packages
+	targetCfg.TemplatePatterns = t.TemplatePatterns
+	targetCfg.ExtractFormat = t.ExtractFormat
+	targetCfg.OutputDir = t.OutputDir
+	targetCfg.OutputFile = t.OutputFile
+	targetCfg.DefaultDomain = t.DefaultDomain
+	return &targetCfg
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareTargets(t *testing.T) {
+	cfg := NewDefault()
+	cfg.OutputDir = t.TempDir()
+	cfg.Targets = []Target{
+		{Name: "server", Packages: []string{"./cmd/server/...", "./internal/..."}},
+		{Name: "web", TemplatePatterns: []string{"web/**/*.html"}, ExtractFormat: "json", DefaultDomain: "web"},
+		{Name: "cli", Packages: []string{"./cmd/cli/...", "./internal/..."}, OutputFile: filepath.Join(cfg.OutputDir, "cli", "cli.pot")},
+	}
+	require.NoError(t, cfg.Prepare())
+
+	assert.Equal(t, []string{"./cmd/server/...", "./internal/...", "./cmd/cli/..."}, cfg.Args)
+	assert.Equal(t, []string{"web/**/*.html"}, cfg.TemplatePatterns)
+	assert.NotEmpty(t, cfg.Keywords, "the template keywords are needed for the targets with templates")
+
+	server := cfg.TargetConfig(cfg.Targets[0])
+	assert.Equal(t, []string{"./cmd/server/...", "./internal/..."}, server.Args)
+	assert.Equal(t, "messages.pot", server.OutputFile)
+	assert.Equal(t, cfg.OutputDir, server.OutputDir)
+
+	web := cfg.TargetConfig(cfg.Targets[1])
+	assert.Equal(t, ExtractFormatJSON, web.ExtractFormat)
+	assert.Equal(t, "web.json", web.OutputFile)
+
+	cli := cfg.TargetConfig(cfg.Targets[2])
+	assert.Equal(t, filepath.Join(cfg.OutputDir, "cli"), cli.OutputDir)
+	assert.Equal(t, "cli.pot", cli.OutputFile)
+}
+
+func TestPrepareTargetsErrors(t *testing.T) {
+	tests := map[string][]Target{
+		"no name":      {{Packages: []string{"./..."}}},
+		"duplicate":    {{Name: "a", Packages: []string{"./..."}}, {Name: "a", Packages: []string{"./..."}}},
+		"empty":        {{Name: "a"}},
+		"wrong format": {{Name: "a", Packages: []string{"./..."}, ExtractFormat: "xml"}},
+	}
+
+	for name, targets := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := NewDefault()
+			cfg.Targets = targets
+			assert.Error(t, cfg.Prepare())
+		})
+	}
+
+	cfg := NewDefault()
+	cfg.Args = []string{"./..."}
+	cfg.Targets = []Target{{Name: "a", Packages: []string{"./..."}}}
+	assert.ErrorContains(t, cfg.Prepare(), "cannot be combined")
+
+	cfg = NewDefault()
+	cfg.TemplatePatterns = []string{"templates/*.html"}
+	cfg.Targets = []Target{{Name: "a", Packages: []string{"./..."}}}
+	assert.ErrorContains(t, cfg.Prepare(), "template patterns cannot be combined")
+}
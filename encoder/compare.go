@@ -0,0 +1,36 @@
+package encoder
+
+import (
+	"bytes"
+	"regexp"
+)
+
+var (
+	rePotCreationDate = regexp.MustCompile(`(?m)^"POT-Creation-Date: .*"\r?\n`)
+	reHeaderEntry     = regexp.MustCompile(`(?m)^msgid ""\r?\nmsgstr ""`)
+)
+
+// EqualContent reports whether two encoded files contain the same content.
+// The POT creation date is ignored, because it changes on every extraction.
+func EqualContent(a, b []byte) bool {
+	return bytes.Equal(removeCreationDate(a), removeCreationDate(b))
+}
+
+// removeCreationDate removes the POT-Creation-Date field from the header entry of a PO file.
+// The header entry is the first entry of the file and ends with an empty line, the messages are not changed.
+func removeCreationDate(content []byte) []byte {
+	end := bytes.Index(content, []byte("\n\n")) + 1
+	if end == 0 {
+		end = len(content)
+	}
+
+	header := content[:end]
+	if !reHeaderEntry.Match(header) {
+		return content
+	}
+
+	header = rePotCreationDate.ReplaceAll(header, nil)
+	res := make([]byte, 0, len(header)+len(content)-end)
+	res = append(res, header...)
+	return append(res, content[end:]...)
+}
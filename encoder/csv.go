@@ -0,0 +1,140 @@
+package encoder
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/vorlif/spreak/catalog/cldrplural"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+// Columns of the CSV files. They are followed by one translation column for each
+// plural category of each language, see CSVTranslationColumn.
+const (
+	CSVColumnContext    = "context"
+	CSVColumnKey        = "key"
+	CSVColumnPlural     = "plural"
+	CSVColumnComments   = "comments"
+	CSVColumnReferences = "references"
+	CSVColumnFlags      = "flags"
+)
+
+// CSVColumns are the columns of a message in the order in which they are written.
+var CSVColumns = []string{
+	CSVColumnContext,
+	CSVColumnKey,
+	CSVColumnPlural,
+	CSVColumnComments,
+	CSVColumnReferences,
+	CSVColumnFlags,
+}
+
+// CSVMessage is a row of a CSV file.
+type CSVMessage struct {
+	Context    string
+	Key        string
+	Plural     string
+	Comments   []string
+	References []string
+	Flags      []string
+	// Translations contains the translations of each language by plural category.
+	// A message without plural only has a translation for the category Other.
+	Translations map[string]map[cldrplural.Category]string
+}
+
+// CSVTranslationColumn returns the name of the translation column of a language and a plural category,
+// e.g. "de[one]".
+func CSVTranslationColumn(lang string, cat cldrplural.Category) string {
+	return lang + "[" + catKey(cat) + "]"
+}
+
+// CSVComma returns the field delimiter of the CSV format, a tab for TSV files and a comma otherwise.
+func CSVComma(format string) rune {
+	if format == config.ExtractFormatTSV {
+		return '\t'
+	}
+	return ','
+}
+
+// WriteCSV writes the messages as CSV file with a translation column for each plural category of the languages.
+// Comments and references are separated by line breaks, flags by commas.
+func WriteCSV(w io.Writer, comma rune, languages []string, messages []*CSVMessage) error {
+	categories := make([][]cldrplural.Category, len(languages))
+	header := append([]string(nil), CSVColumns...)
+	for i, lang := range languages {
+		categories[i] = PluralCategories(lang)
+		for _, cat := range categories[i] {
+			header = append(header, CSVTranslationColumn(lang, cat))
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		record := []string{
+			msg.Context,
+			msg.Key,
+			msg.Plural,
+			strings.Join(msg.Comments, "\n"),
+			strings.Join(msg.References, "\n"),
+			strings.Join(msg.Flags, ", "),
+		}
+		for i, lang := range languages {
+			for _, cat := range categories[i] {
+				record = append(record, msg.Translations[lang][cat])
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type csvEncoder struct {
+	cfg *config.Config
+	w   io.Writer
+}
+
+// NewCSVEncoder creates an encoder for CSV and TSV files, depending on the configured format.
+// If a target language is configured, the file contains empty translation columns for its plural categories.
+func NewCSVEncoder(cfg *config.Config, w io.Writer) Encoder {
+	return &csvEncoder{cfg: cfg, w: w}
+}
+
+func (e *csvEncoder) Encode(issues []extract.Issue) error {
+	messages := sortedMessages(e.cfg, buildMessages(e.cfg, issues))
+
+	rows := make([]*CSVMessage, 0, len(messages))
+	for _, msg := range messages {
+		row := &CSVMessage{
+			Context: msg.Context,
+			Key:     msg.ID,
+			Plural:  msg.IDPlural,
+			Flags:   msg.Comment.Flags,
+		}
+		if msg.Comment.Extracted != "" {
+			row.Comments = strings.Split(msg.Comment.Extracted, "\n")
+		}
+		for _, ref := range writtenReferences(e.cfg, msg) {
+			row.References = append(row.References, referenceString(ref))
+		}
+		rows = append(rows, row)
+	}
+
+	var languages []string
+	if e.cfg.TargetLanguage != "" {
+		languages = append(languages, e.cfg.TargetLanguage)
+	}
+
+	return WriteCSV(e.w, CSVComma(e.cfg.ExtractFormat), languages, rows)
+}
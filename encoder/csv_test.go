@@ -0,0 +1,61 @@
+package encoder
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vorlif/spreak/catalog/cldrplural"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+func encodeCSV(t *testing.T, format, targetLanguage string) string {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.NewDefault()
+	cfg.OutputDir = dir
+	cfg.ExtractFormat = format
+	cfg.TargetLanguage = targetLanguage
+	require.NoError(t, cfg.Prepare())
+
+	var buf bytes.Buffer
+	require.NoError(t, NewCSVEncoder(cfg, &buf).Encode(xliffTestIssues(dir)))
+	return buf.String()
+}
+
+func TestCSVEncoder(t *testing.T) {
+	t.Run("csv without target language", func(t *testing.T) {
+		want := "context,key,plural,comments,references,flags\n" +
+			",Hello %s,,Greeting,main.go:3,\"go-format, max-length:20\"\n" +
+			"files,%d file,%d files,,main.go:7,go-format\n"
+		assert.Equal(t, want, encodeCSV(t, config.ExtractFormatCSV, ""))
+	})
+
+	t.Run("tsv with target language", func(t *testing.T) {
+		want := "context\tkey\tplural\tcomments\treferences\tflags\tpl[one]\tpl[few]\tpl[many]\tpl[other]\n" +
+			"\tHello %s\t\tGreeting\tmain.go:3\tgo-format, max-length:20\t\t\t\t\n" +
+			"files\t%d file\t%d files\t\tmain.go:7\tgo-format\t\t\t\t\n"
+		assert.Equal(t, want, encodeCSV(t, config.ExtractFormatTSV, "pl"))
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	messages := []*CSVMessage{
+		{
+			Key:        "Line one\nLine two",
+			Comments:   []string{"First", "Second"},
+			References: []string{"a.go:1", "b.go:2"},
+			Translations: map[string]map[cldrplural.Category]string{
+				"de": {cldrplural.Other: "Zeile \"eins\"\nZeile zwei"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, ',', []string{"de"}, messages))
+	want := "context,key,plural,comments,references,flags,de[one],de[other]\n" +
+		",\"Line one\nLine two\",,\"First\nSecond\",\"a.go:1\nb.go:2\",,,\"Zeile \"\"eins\"\"\nZeile zwei\"\n"
+	assert.Equal(t, want, buf.String())
+}
@@ -1,9 +1,16 @@
 package encoder
 
 import (
+	"bytes"
+	"go/token"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
 )
 
 func TestReGoStringFormat(t *testing.T) {
@@ -53,3 +60,88 @@ func TestReGoStringFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestPotEncoderFormatFlags(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.OmitHeader = true
+	require.NoError(t, cfg.Prepare())
+
+	issues := []extract.Issue{
+		{MsgID: "detected %d"},
+		{MsgID: "disabled %d", Flags: []string{"no-go-format"}},
+		{MsgID: "forced", Flags: []string{"go-format"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewPotEncoder(cfg, &buf).Encode(issues))
+	out := buf.String()
+	assert.Contains(t, out, "#, go-format\nmsgid \"detected %d\"")
+	assert.Contains(t, out, "#, no-go-format\nmsgid \"disabled %d\"")
+	assert.Contains(t, out, "#, go-format\nmsgid \"forced\"")
+	assert.NotContains(t, out, "go-format, go-format")
+}
+
+func TestEqualContent(t *testing.T) {
+	pot := func(date, msgid string) []byte {
+		return []byte("msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: PACKAGE VERSION\\n\"\n\"POT-Creation-Date: " + date + "\\n\"\n\n" +
+			"msgid \"" + msgid + "\"\nmsgstr \"\"\n")
+	}
+
+	assert.True(t, EqualContent(pot("2024-01-01 10:00+0000", "a"), pot("2025-02-02 11:11+0100", "a")))
+	assert.False(t, EqualContent(pot("2024-01-01 10:00+0000", "a"), pot("2024-01-01 10:00+0000", "b")))
+	assert.False(t, EqualContent(pot("2024-01-01 10:00+0000", "a\"\n\"POT-Creation-Date: 1\\n"), pot("2024-01-01 10:00+0000", "a\"\n\"POT-Creation-Date: 2\\n")))
+	assert.True(t, EqualContent([]byte(`{"a": ""}`), []byte(`{"a": ""}`)))
+	assert.False(t, EqualContent([]byte(`{"a": ""}`), []byte(`{"b": ""}`)))
+}
+
+func TestPotEncoderLocation(t *testing.T) {
+	dir := t.TempDir()
+	issues := []extract.Issue{
+		{MsgID: "hello", Comments: []string{"Greeting"}, Pos: token.Position{Filename: filepath.Join(dir, "src", "a.go"), Line: 3}},
+		{MsgID: "hello", Pos: token.Position{Filename: filepath.Join(dir, "src", "a.go"), Line: 9}},
+	}
+
+	encode := func(t *testing.T, cfg *config.Config) string {
+		t.Helper()
+		cfg.OmitHeader = true
+		require.NoError(t, cfg.Prepare())
+		var buf bytes.Buffer
+		require.NoError(t, NewPotEncoder(cfg, &buf).Encode(issues))
+		return buf.String()
+	}
+
+	cfg := config.NewDefault()
+	cfg.OutputDir = filepath.Join(dir, "locale")
+	assert.Equal(t, "#. Greeting\n#: ../src/a.go:3 ../src/a.go:9\nmsgid \"hello\"\nmsgstr \"\"\n", encode(t, cfg))
+
+	cfg = config.NewDefault()
+	cfg.OutputDir = filepath.Join(dir, "locale")
+	cfg.ReferenceBase = dir
+	cfg.AddLocation = config.LocationFile
+	assert.Equal(t, "#. Greeting\n#: src/a.go\nmsgid \"hello\"\nmsgstr \"\"\n", encode(t, cfg))
+
+	cfg = config.NewDefault()
+	cfg.AddLocation = config.LocationNever
+	assert.Equal(t, "#. Greeting\nmsgid \"hello\"\nmsgstr \"\"\n", encode(t, cfg))
+
+	cfg = config.NewDefault()
+	cfg.WriteNoLocation = true
+	cfg.ReferenceBase = filepath.Join(dir, "src")
+	cfg.ReferenceRoot = dir
+	cfg.ReferenceURL = "https://git.example/blob/{rev}/{path}#L{line}"
+	cfg.ReferenceRevision = "abc123"
+	assert.Equal(t, "#. Greeting\n#. https://git.example/blob/abc123/src/a.go#L3\n#. https://git.example/blob/abc123/src/a.go#L9\n"+
+		"msgid \"hello\"\nmsgstr \"\"\n", encode(t, cfg))
+
+	// Without reference base the references are relative to the output directory, the URL to the source directory
+	cfg = config.NewDefault()
+	cfg.OutputDir = filepath.Join(dir, "locale")
+	cfg.SourceDir = dir
+	cfg.ReferenceURL = "https://git.example/{path}#L{line}"
+	assert.Equal(t, "#. Greeting\n#. https://git.example/src/a.go#L3\n#. https://git.example/src/a.go#L9\n"+
+		"#: ../src/a.go:3 ../src/a.go:9\nmsgid \"hello\"\nmsgstr \"\"\n", encode(t, cfg))
+
+	cfg = config.NewDefault()
+	cfg.AddLocation = "lines"
+	assert.Error(t, cfg.Prepare())
+}
@@ -0,0 +1,182 @@
+package encoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/vorlif/spreak/catalog/po"
+)
+
+const defaultLanguageTeam = "LANGUAGE <LL@li.org>"
+
+// HeaderData are the values that can be used as placeholders in a header template,
+// e.g. {{.PackageName}} or {{.Year}}.
+type HeaderData struct {
+	PackageName     string
+	CopyrightHolder string
+	BugsAddress     string
+	LanguageTeam    string
+	CreationDate    string
+	Year            string
+}
+
+// ReadHeader reads the header of an existing PO or POT file.
+// If the file does not exist, nil is returned without an error.
+func ReadHeader(path string) (*po.Header, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	file, err := po.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("header of %s could not be read: %w", path, err)
+	}
+	return file.Header, nil
+}
+
+func (e *potEncoder) buildHeader() (*po.Header, error) {
+	now := time.Now()
+	data := HeaderData{
+		PackageName:     e.cfg.PackageName,
+		CopyrightHolder: e.cfg.CopyrightHolder,
+		BugsAddress:     e.cfg.BugsAddress,
+		LanguageTeam:    e.cfg.LanguageTeam,
+		CreationDate:    now.Format("2006-01-02 15:04-0700"),
+		Year:            now.Format("2006"),
+	}
+	if data.LanguageTeam == "" {
+		data.LanguageTeam = defaultLanguageTeam
+	}
+
+	var header *po.Header
+	switch {
+	case e.previous != nil:
+		copied := *e.previous
+		header = &copied
+	case e.cfg.HeaderTemplate != "":
+		var err error
+		if header, err = readHeaderTemplate(e.cfg.HeaderTemplate, data); err != nil {
+			return nil, err
+		}
+	default:
+		header = placeholderHeader(data)
+	}
+
+	header.POTCreationDate = data.CreationDate
+	if e.cfg.LanguageTeam != "" {
+		header.LanguageTeam = e.cfg.LanguageTeam
+	}
+	if e.cfg.Generator != "" {
+		header.XGenerator = e.cfg.Generator
+	}
+
+	if len(header.UnknownFields) > 0 {
+		fields := make(map[string]string, len(header.UnknownFields))
+		for name, value := range header.UnknownFields {
+			fields[name] = value
+		}
+		header.UnknownFields = fields
+	}
+	for _, field := range e.cfg.HeaderFields {
+		header.Set(field.Name, field.Value)
+	}
+
+	return header, nil
+}
+
+// readHeaderTemplate executes the template file and returns the header of the resulting PO file.
+func readHeaderTemplate(path string, data HeaderData) (*po.Header, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("header template could not be read: %w", err)
+	}
+
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("header template could not be parsed: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("header template could not be executed: %w", err)
+	}
+
+	file, err := po.Parse(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("header template is not a valid PO file: %w", err)
+	}
+	return file.Header, nil
+}
+
+func placeholderHeader(data HeaderData) *po.Header {
+	headerComment := fmt.Sprintf(`SOME DESCRIPTIVE TITLE.
+Copyright (C) YEAR %s
+This file is distributed under the same license as the %s package.
+FIRST AUTHOR <EMAIL@ADDRESS>, YEAR.
+`, data.CopyrightHolder, data.PackageName)
+	return &po.Header{
+		Comment: &po.Comment{
+			Translator:     headerComment,
+			Extracted:      "",
+			References:     nil,
+			Flags:          []string{"fuzzy"},
+			PrevMsgContext: "",
+			PrevMsgID:      "",
+		},
+		ProjectIDVersion:        data.PackageName,
+		ReportMsgidBugsTo:       data.BugsAddress,
+		POTCreationDate:         data.CreationDate,
+		PORevisionDate:          "YEAR-MO-DA HO:MI+ZONE",
+		LastTranslator:          "FULL NAME <EMAIL@ADDRESS>",
+		LanguageTeam:            data.LanguageTeam,
+		Language:                "",
+		MimeVersion:             "1.0",
+		ContentType:             "text/plain; charset=UTF-8",
+		ContentTransferEncoding: "8bit",
+		PluralForms:             "", // alternative  "nplurals=INTEGER; plural=EXPRESSION;"
+	}
+}
+
+// insertHeaderFields appends the fields sorted by name to the header entry of an encoded file.
+func insertHeaderFields(out []byte, fields map[string]string) []byte {
+	if len(fields) == 0 {
+		return out
+	}
+
+	// The header entry is the first entry of the file and ends with an empty line.
+	end := bytes.Index(out, []byte("\n\n"))
+	if end < 0 {
+		return out
+	}
+	end++
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&lines, "\"%s: %s\\n\"\n", name, escapeHeaderValue(fields[name]))
+	}
+
+	res := make([]byte, 0, len(out)+lines.Len())
+	res = append(res, out[:end]...)
+	res = append(res, lines.Bytes()...)
+	return append(res, out[end:]...)
+}
+
+func escapeHeaderValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
@@ -0,0 +1,106 @@
+package encoder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+func encodeHeader(t *testing.T, cfg *config.Config, path string) string {
+	t.Helper()
+	cfg.WriteNoLocation = true
+	require.NoError(t, cfg.Prepare())
+
+	previous, err := ReadHeader(path)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewPotEncoderWithHeader(cfg, &buf, previous).Encode([]extract.Issue{{MsgID: "hello"}}))
+	return buf.String()
+}
+
+func TestPotEncoderHeader(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("placeholder header", func(t *testing.T) {
+		cfg := config.NewDefault()
+		cfg.PackageName = "app 1.0"
+		out := encodeHeader(t, cfg, filepath.Join(dir, "missing.pot"))
+		assert.Contains(t, out, "# SOME DESCRIPTIVE TITLE.\n")
+		assert.Contains(t, out, "\"Project-Id-Version: app 1.0\\n\"\n")
+		assert.Contains(t, out, "\"Language-Team: LANGUAGE <LL@li.org>\\n\"\n")
+		assert.NotContains(t, out, "X-Generator")
+	})
+
+	t.Run("configured fields", func(t *testing.T) {
+		cfg := config.NewDefault()
+		cfg.LanguageTeam = "German <de@example.com>"
+		cfg.Generator = "xspreak"
+		cfg.HeaderFields = []config.HeaderField{{Name: "X-Zeta", Value: "z"}, {Name: "X-Alpha", Value: `a "quoted"`}}
+		out := encodeHeader(t, cfg, "")
+		assert.Contains(t, out, "\"Language-Team: German <de@example.com>\\n\"\n")
+		assert.Contains(t, out, "\"X-Generator: xspreak\\n\"\n\"X-Alpha: a \\\"quoted\\\"\\n\"\n\"X-Zeta: z\\n\"\n\n")
+	})
+
+	t.Run("template", func(t *testing.T) {
+		tmplPath := filepath.Join(dir, "header.pot")
+		require.NoError(t, os.WriteFile(tmplPath, []byte(`# Translations of {{.PackageName}}.
+# Copyright (C) {{.Year}} {{.CopyrightHolder}}
+msgid ""
+msgstr ""
+"Project-Id-Version: {{.PackageName}}\n"
+"Language-Team: {{.LanguageTeam}}\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"X-Project: internal\n"
+`), 0o600))
+
+		cfg := config.NewDefault()
+		cfg.PackageName = "app"
+		cfg.CopyrightHolder = "ACME"
+		cfg.HeaderTemplate = tmplPath
+		out := encodeHeader(t, cfg, "")
+		assert.Contains(t, out, "# Translations of app.\n# Copyright (C) ")
+		assert.Contains(t, out, " ACME\n")
+		assert.Contains(t, out, "\"Project-Id-Version: app\\n\"\n")
+		assert.Contains(t, out, "\"POT-Creation-Date: 2")
+		assert.Contains(t, out, "\"X-Project: internal\\n\"\n")
+		assert.NotContains(t, out, "SOME DESCRIPTIVE TITLE")
+
+		cfg.HeaderTemplate = filepath.Join(dir, "invalid.pot")
+		require.NoError(t, os.WriteFile(cfg.HeaderTemplate, []byte("{{.Unknown}}"), 0o600))
+		var buf bytes.Buffer
+		assert.Error(t, NewPotEncoder(cfg, &buf).Encode(nil))
+	})
+
+	t.Run("keep existing header", func(t *testing.T) {
+		existing := filepath.Join(dir, "existing.pot")
+		require.NoError(t, os.WriteFile(existing, []byte(`# Edited by hand.
+msgid ""
+msgstr ""
+"Project-Id-Version: edited 2.0\n"
+"POT-Creation-Date: 2020-01-01 10:00+0000\n"
+"Language-Team: Team <team@example.com>\n"
+"X-Custom: kept\n"
+
+msgid "old"
+msgstr ""
+`), 0o600))
+
+		cfg := config.NewDefault()
+		cfg.HeaderFields = []config.HeaderField{{Name: "X-Added", Value: "new"}}
+		out := encodeHeader(t, cfg, existing)
+		assert.Contains(t, out, "# Edited by hand.\n")
+		assert.Contains(t, out, "\"Project-Id-Version: edited 2.0\\n\"\n")
+		assert.Contains(t, out, "\"Language-Team: Team <team@example.com>\\n\"\n")
+		assert.Contains(t, out, "\"X-Added: new\\n\"\n\"X-Custom: kept\\n\"\n")
+		assert.NotContains(t, out, "2020-01-01")
+		assert.NotContains(t, out, "\"old\"")
+	})
+}
@@ -13,18 +13,26 @@ import (
 
 	"github.com/vorlif/xspreak/extract"
 	"github.com/vorlif/xspreak/extract/etype"
+	"github.com/vorlif/xspreak/nestedjson"
 	"github.com/vorlif/xspreak/util"
 )
 
 type jsonEncoder struct {
-	w *json.Encoder
+	w         *json.Encoder
+	separator string
 }
 
 func NewJSONEncoder(w io.Writer, ident string) Encoder {
+	return NewNestedJSONEncoder(w, ident, "")
+}
+
+// NewNestedJSONEncoder creates an encoder that splits the keys at the separator into nested objects.
+// If the separator is empty, the keys are not split.
+func NewNestedJSONEncoder(w io.Writer, ident, separator string) Encoder {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", ident)
 
-	return &jsonEncoder{w: enc}
+	return &jsonEncoder{w: enc, separator: separator}
 }
 
 func (e *jsonEncoder) Encode(issues []extract.Issue) error {
@@ -32,15 +40,14 @@ func (e *jsonEncoder) Encode(issues []extract.Issue) error {
 
 	items := make(map[string]JSONItem, len(issues))
 
-	for _, iss := range issues {
+	for _, iss := range sortIssues(issues) {
 		msg := make(JSONMessage)
 		msg[catKey(cldrplural.Other)] = ""
 
-		key := iss.MsgID
+		key := jsonKey(iss)
 
 		if iss.Context != "" {
 			msg["context"] = iss.Context
-			key = fmt.Sprintf("%s_%s", iss.MsgID, iss.Context)
 		}
 
 		if iss.PluralID != "" {
@@ -68,7 +75,32 @@ func (e *jsonEncoder) Encode(issues []extract.Issue) error {
 		return file[i].Key < file[j].Key
 	})
 
-	return e.w.Encode(file)
+	if e.separator == "" {
+		return e.w.Encode(file)
+	}
+
+	flat, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	nested, err := nestedjson.Nest(flat, e.separator)
+	if err != nil {
+		return err
+	}
+	return e.w.Encode(json.RawMessage(nested))
+}
+
+// jsonKey returns the key of an issue in a JSON file.
+func jsonKey(iss extract.Issue) string {
+	return JSONKey(iss.Context, iss.MsgID)
+}
+
+// JSONKey returns the key of a message in a JSON file.
+func JSONKey(context, msgID string) string {
+	if context != "" {
+		return fmt.Sprintf("%s_%s", msgID, context)
+	}
+	return msgID
 }
 
 type JSONItem struct {
@@ -0,0 +1,85 @@
+package encoder
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/util"
+)
+
+// JSONMeta contains the metadata of the messages of a JSON file, by the key of the message.
+// It is stored next to the JSON file, so that spreak can load the JSON file unchanged.
+type JSONMeta map[string]*JSONMetaItem
+
+// JSONMetaItem is the metadata of a single message.
+type JSONMetaItem struct {
+	// Comments are the extracted comments for the translators.
+	Comments []string `json:"comments,omitempty"`
+	// TranslatorComments are comments of the translators, they are only kept by the merge.
+	TranslatorComments []string `json:"translatorComments,omitempty"`
+	// References are the positions of the message in the source code.
+	References []string `json:"references,omitempty"`
+	Flags      []string `json:"flags,omitempty"`
+}
+
+// MetaFileName returns the name of the metadata file of a JSON file, e.g. "de.meta.json" for "de.json".
+func MetaFileName(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".meta.json"
+}
+
+type jsonMetaEncoder struct {
+	cfg *config.Config
+	w   *json.Encoder
+}
+
+// NewJSONMetaEncoder creates an encoder that writes the comments, references and flags
+// of the messages of a JSON file.
+func NewJSONMetaEncoder(cfg *config.Config, w io.Writer, ident string) Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", ident)
+
+	return &jsonMetaEncoder{cfg: cfg, w: enc}
+}
+
+func (e *jsonMetaEncoder) Encode(issues []extract.Issue) error {
+	util.TrackTime(time.Now(), "Build metadata")
+
+	refs := newReferencer(e.cfg)
+	meta := make(JSONMeta, len(issues))
+	for _, iss := range sortIssues(issues) {
+		key := jsonKey(iss)
+		item, ok := meta[key]
+		if !ok {
+			item = &JSONMetaItem{}
+			meta[key] = item
+		}
+
+		ref, comments := refs.reference(iss)
+		item.Comments = appendUnique(item.Comments, comments...)
+		item.Flags = appendUnique(item.Flags, issueFlags(iss)...)
+		if e.cfg.AddLocation != config.LocationNever {
+			item.References = appendUnique(item.References, referenceString(ref))
+		}
+	}
+
+	for _, item := range meta {
+		slices.Sort(item.Flags)
+	}
+
+	return e.w.Encode(meta)
+}
+
+// appendUnique appends the values that are not yet contained in list.
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" && !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -3,11 +3,14 @@ package encoder
 import (
 	"bytes"
 	"encoding/json"
+	"go/token"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/vorlif/xspreak/config"
 	"github.com/vorlif/xspreak/extract"
 	"github.com/vorlif/xspreak/extract/etype"
 )
@@ -44,6 +47,35 @@ func TestJSONEncoder(t *testing.T) {
 	})
 }
 
+func TestNestedJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewNestedJSONEncoder(&buf, "", ".")
+
+	t.Run("keys are nested", func(t *testing.T) {
+		buf.Reset()
+
+		err := enc.Encode([]extract.Issue{
+			{MsgID: "settings.profile.title", IDToken: etype.Key},
+			{MsgID: "settings.profile.items", PluralID: "settings.profile.items", IDToken: etype.PluralKey},
+			{MsgID: "settings.save", IDToken: etype.Key},
+		})
+		require.NoError(t, err)
+		want := `{"settings":{"profile":{"items":{"one":"","other":""},"title":""},"save":""}}
+`
+		assert.Equal(t, want, buf.String())
+	})
+
+	t.Run("leaf and branch conflict", func(t *testing.T) {
+		buf.Reset()
+
+		err := enc.Encode([]extract.Issue{
+			{MsgID: "settings", IDToken: etype.Key},
+			{MsgID: "settings.title", IDToken: etype.Key},
+		})
+		assert.ErrorContains(t, err, `"settings" is a message and a prefix of the key "settings.title"`)
+	})
+}
+
 func TestJSONMessage_MarshalJSON(t *testing.T) {
 	t.Run("empty returns empty string", func(t *testing.T) {
 		msg := make(JSONMessage)
@@ -123,3 +155,26 @@ func TestJSONMessage_UnmarshalJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestJSONMetaEncoder(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.NewDefault()
+	cfg.OutputDir = dir
+	require.NoError(t, cfg.Prepare())
+
+	var buf bytes.Buffer
+	err := NewJSONMetaEncoder(cfg, &buf, "").Encode([]extract.Issue{
+		{MsgID: "id", Comments: []string{"A comment"}, Pos: token.Position{Filename: filepath.Join(dir, "b.go"), Line: 4}},
+		{MsgID: "id", Comments: []string{"A comment"}, Flags: []string{"no-wrap"}, Pos: token.Position{Filename: filepath.Join(dir, "a.go"), Line: 2}},
+		{Context: "ctx", MsgID: "%d items", Pos: token.Position{Filename: filepath.Join(dir, "a.go"), Line: 7}},
+	})
+	require.NoError(t, err)
+
+	want := `{
+	"id": {"comments": ["A comment"], "references": ["a.go:2", "b.go:4"], "flags": ["no-wrap"]},
+	"%d items_ctx": {"references": ["a.go:7"], "flags": ["go-format"]}
+}`
+	assert.JSONEq(t, want, buf.String())
+
+	assert.Equal(t, filepath.Join("locale", "de.meta.json"), MetaFileName(filepath.Join("locale", "de.json")))
+}
@@ -1,14 +1,11 @@
 package encoder
 
 import (
-	"fmt"
+	"bytes"
 	"io"
-	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/vorlif/spreak/catalog/po"
 
 	"github.com/vorlif/xspreak/config"
@@ -17,100 +14,94 @@ import (
 )
 
 type potEncoder struct {
-	cfg *config.Config
-	w   *po.Encoder
+	cfg      *config.Config
+	w        io.Writer
+	previous *po.Header
 }
 
 func NewPotEncoder(cfg *config.Config, w io.Writer) Encoder {
-	enc := po.NewEncoder(w)
-	enc.SetWrapWidth(cfg.WrapWidth)
-	enc.SetWriteHeader(!cfg.OmitHeader)
-	enc.SetWriteReferences(!cfg.WriteNoLocation)
+	return NewPotEncoderWithHeader(cfg, w, nil)
+}
 
-	return &potEncoder{cfg: cfg, w: enc}
+// NewPotEncoderWithHeader creates an encoder that writes the header of a previous output file.
+// Only the creation date and the fields set in the configuration are updated.
+// If previous is nil, the header is created from the configuration.
+func NewPotEncoderWithHeader(cfg *config.Config, w io.Writer, previous *po.Header) Encoder {
+	return &potEncoder{cfg: cfg, w: w, previous: previous}
 }
 
 func (e *potEncoder) Encode(issues []extract.Issue) error {
+	header, err := e.buildHeader()
+	if err != nil {
+		return err
+	}
+
+	// The po encoder writes the unknown fields in random order, they are inserted sorted afterwards.
+	extraFields := header.UnknownFields
+	header.UnknownFields = nil
+
 	file := &po.File{
-		Header:   e.buildHeader(),
-		Messages: make(map[string]map[string]*po.Message),
+		Header:   header,
+		Messages: buildMessages(e.cfg, issues),
 	}
 
-	for _, msg := range e.buildMessages(issues) {
-		file.AddMessage(msg)
+	var buf bytes.Buffer
+	enc := po.NewEncoder(&buf)
+	enc.SetWrapWidth(e.cfg.WrapWidth)
+	enc.SetWriteHeader(!e.cfg.OmitHeader)
+	enc.SetWriteReferences(e.cfg.AddLocation != config.LocationNever)
+	enc.SetSortFunction(messageOrder(e.cfg))
+
+	if err := enc.Encode(file); err != nil {
+		return err
 	}
 
-	return e.w.Encode(file)
+	out := buf.Bytes()
+	if !e.cfg.OmitHeader {
+		out = insertHeaderFields(out, extraFields)
+	}
+	if e.cfg.CreationDate == config.CreationDateOmit {
+		out = removeCreationDate(out)
+	}
+
+	_, err = e.w.Write(out)
+	return err
 }
 
-func (e *potEncoder) buildMessages(issues []extract.Issue) []*po.Message {
+// buildMessages merges the issues into messages.
+// The references of the messages are sorted and free of duplicates.
+func buildMessages(cfg *config.Config, issues []extract.Issue) po.Messages {
 	util.TrackTime(time.Now(), "Build messages")
-	messages := make([]*po.Message, 0, len(issues))
+	messages := make(po.Messages)
 
-	absOut, errA := filepath.Abs(e.cfg.OutputDir)
-	if errA != nil {
-		absOut = e.cfg.OutputDir
-	}
+	refs := newReferencer(cfg)
 
-	for _, iss := range issues {
-		path, errP := filepath.Rel(absOut, iss.Pos.Filename)
-		if errP != nil {
-			logrus.WithError(errP).Warn("Relative path could not be created, use absolute")
-			path = iss.Pos.Filename
+	for _, iss := range sortIssues(issues) {
+		if iss.MsgID == "" {
+			continue
 		}
 
-		ref := &po.Reference{
-			Path:   filepath.ToSlash(path),
-			Line:   iss.Pos.Line,
-			Column: iss.Pos.Column,
-		}
-
-		if reGoStringFormat.MatchString(iss.MsgID) || reGoStringFormat.MatchString(iss.PluralID) {
-			iss.Flags = append(iss.Flags, "go-format")
-		}
+		ref, comments := refs.reference(iss)
 
 		msg := &po.Message{
 			Comment: &po.Comment{
-				Extracted:  strings.Join(iss.Comments, "\n"),
+				Extracted:  strings.Join(comments, "\n"),
 				References: []*po.Reference{ref},
-				Flags:      iss.Flags,
+				Flags:      issueFlags(iss),
 			},
 			Context:  iss.Context,
 			ID:       iss.MsgID,
 			IDPlural: iss.PluralID,
 		}
 
-		messages = append(messages, msg)
+		messages.Add(msg)
 	}
 
-	return messages
-}
-
-func (e *potEncoder) buildHeader() *po.Header {
-	headerComment := fmt.Sprintf(`SOME DESCRIPTIVE TITLE.
-Copyright (C) YEAR %s
-This file is distributed under the same license as the %s package.
-FIRST AUTHOR <EMAIL@ADDRESS>, YEAR.
-`, e.cfg.CopyrightHolder, e.cfg.PackageName)
-	return &po.Header{
-		Comment: &po.Comment{
-			Translator:     headerComment,
-			Extracted:      "",
-			References:     nil,
-			Flags:          []string{"fuzzy"},
-			PrevMsgContext: "",
-			PrevMsgID:      "",
-		},
-		ProjectIDVersion:        e.cfg.PackageName,
-		ReportMsgidBugsTo:       e.cfg.BugsAddress,
-		POTCreationDate:         time.Now().Format("2006-01-02 15:04-0700"),
-		PORevisionDate:          "YEAR-MO-DA HO:MI+ZONE",
-		LastTranslator:          "FULL NAME <EMAIL@ADDRESS>",
-		LanguageTeam:            "LANGUAGE <LL@li.org>",
-		Language:                "",
-		MimeVersion:             "1.0",
-		ContentType:             "text/plain; charset=UTF-8",
-		ContentTransferEncoding: "8bit",
-		PluralForms:             "", // alternative  "nplurals=INTEGER; plural=EXPRESSION;"
+	for _, byID := range messages {
+		for _, msg := range byID {
+			normalizeReferences(msg)
+		}
 	}
+
+	return messages
 }
@@ -0,0 +1,100 @@
+package encoder
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/vorlif/spreak/catalog/po"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+// referencer creates the references and reference URLs of the issues as configured.
+type referencer struct {
+	cfg  *config.Config
+	base string
+	// root is the directory to which the path of the reference URL is relative.
+	root string
+}
+
+func newReferencer(cfg *config.Config) *referencer {
+	base := cfg.ReferenceBase
+	if base == "" {
+		base = cfg.OutputDir
+	}
+	root := cfg.ReferenceRoot
+	if root == "" {
+		root = cfg.SourceDir
+	}
+
+	return &referencer{cfg: cfg, base: absPath(base), root: absPath(root)}
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// reference returns the reference to the position of an issue and the comments of the issue,
+// including the reference URL if one is configured.
+func (r *referencer) reference(iss extract.Issue) (*po.Reference, []string) {
+	ref := &po.Reference{
+		Path:   relativePath(r.base, iss.Pos.Filename),
+		Line:   iss.Pos.Line,
+		Column: iss.Pos.Column,
+	}
+	if r.cfg.AddLocation == config.LocationFile {
+		ref.Line, ref.Column = 0, 0
+	}
+
+	comments := iss.Comments
+	if r.cfg.ReferenceURL != "" {
+		comments = append(slices.Clone(comments), r.url(relativePath(r.root, iss.Pos.Filename), iss.Pos.Line))
+	}
+
+	return ref, comments
+}
+
+// relativePath returns the path of a file relative to base with forward slashes.
+func relativePath(base, filename string) string {
+	path, err := filepath.Rel(base, filename)
+	if err != nil {
+		logrus.WithError(err).Warn("Relative path could not be created, use absolute")
+		path = filename
+	}
+	return filepath.ToSlash(path)
+}
+
+// referenceString formats a reference like the po encoder, without the column.
+func referenceString(ref *po.Reference) string {
+	if ref.Line > 0 {
+		return ref.Path + ":" + strconv.Itoa(ref.Line)
+	}
+	return ref.Path
+}
+
+// url returns the link to a position in the source code.
+func (r *referencer) url(path string, line int) string {
+	return strings.NewReplacer(
+		"{rev}", r.cfg.ReferenceRevision,
+		"{path}", path,
+		"{line}", strconv.Itoa(line),
+	).Replace(r.cfg.ReferenceURL)
+}
+
+// issueFlags returns the flags of an issue, with go-format if the message contains formatting verbs.
+func issueFlags(iss extract.Issue) []string {
+	// The detection can be overridden with the go-format and no-go-format directives
+	hasFormatFlag := slices.Contains(iss.Flags, "go-format") || slices.Contains(iss.Flags, "no-go-format")
+	if !hasFormatFlag && (reGoStringFormat.MatchString(iss.MsgID) || reGoStringFormat.MatchString(iss.PluralID)) {
+		return append(slices.Clone(iss.Flags), "go-format")
+	}
+	return iss.Flags
+}
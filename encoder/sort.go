@@ -0,0 +1,112 @@
+package encoder
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/vorlif/spreak/catalog/po"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+// sortIssues returns the issues ordered by their position in the source code.
+// The extraction order depends on the extractors and the load order of the packages,
+// sorting the issues first makes the merged comments and flags of a message independent of it.
+func sortIssues(issues []extract.Issue) []extract.Issue {
+	sorted := slices.Clone(issues)
+	slices.SortStableFunc(sorted, func(a, b extract.Issue) int {
+		return cmp.Or(
+			cmp.Compare(a.Pos.Filename, b.Pos.Filename),
+			cmp.Compare(a.Pos.Line, b.Pos.Line),
+			cmp.Compare(a.Pos.Column, b.Pos.Column),
+			cmp.Compare(a.Domain, b.Domain),
+			cmp.Compare(a.Context, b.Context),
+			cmp.Compare(a.MsgID, b.MsgID),
+			cmp.Compare(a.PluralID, b.PluralID),
+		)
+	})
+	return sorted
+}
+
+// messageOrder returns the configured order of the messages.
+func messageOrder(cfg *config.Config) func(a, b *po.Message) int {
+	switch {
+	case cfg.SortOutput:
+		return compareByMsgID
+	case cfg.SortByFile:
+		return compareByFile
+	default:
+		return po.DefaultSortFunction
+	}
+}
+
+// sortedMessages returns the messages in the configured order.
+func sortedMessages(cfg *config.Config, messages po.Messages) []*po.Message {
+	list := make([]*po.Message, 0, len(messages))
+	for _, byID := range messages {
+		for _, msg := range byID {
+			list = append(list, msg)
+		}
+	}
+	order := messageOrder(cfg)
+	slices.SortFunc(list, func(a, b *po.Message) int {
+		return cmp.Or(order(a, b), compareByMsgID(a, b))
+	})
+	return list
+}
+
+// normalizeReferences sorts the references of a message and removes duplicates.
+func normalizeReferences(msg *po.Message) {
+	if msg.Comment == nil {
+		return
+	}
+
+	slices.SortFunc(msg.Comment.References, compareReferences)
+	msg.Comment.References = slices.CompactFunc(msg.Comment.References, func(a, b *po.Reference) bool {
+		return a.Equal(b)
+	})
+}
+
+func compareReferences(a, b *po.Reference) int {
+	return cmp.Or(
+		strings.Compare(a.Path, b.Path),
+		cmp.Compare(a.Line, b.Line),
+		cmp.Compare(a.Column, b.Column),
+	)
+}
+
+// compareByMsgID orders messages by msgid, then by context, like xgettext --sort-output.
+func compareByMsgID(a, b *po.Message) int {
+	return cmp.Or(
+		strings.Compare(a.ID, b.ID),
+		strings.Compare(a.Context, b.Context),
+		strings.Compare(a.IDPlural, b.IDPlural),
+	)
+}
+
+// compareByFile orders messages by their references, like xgettext --sort-by-file.
+// Messages without references are written last, messages with the same references are ordered by msgid.
+func compareByFile(a, b *po.Message) int {
+	refsA, refsB := messageReferences(a), messageReferences(b)
+	if (len(refsA) == 0) != (len(refsB) == 0) {
+		if len(refsA) == 0 {
+			return 1
+		}
+		return -1
+	}
+
+	if c := slices.CompareFunc(refsA, refsB, compareReferences); c != 0 {
+		return c
+	}
+
+	return compareByMsgID(a, b)
+}
+
+func messageReferences(msg *po.Message) []*po.Reference {
+	if msg.Comment == nil {
+		return nil
+	}
+	return msg.Comment.References
+}
@@ -0,0 +1,98 @@
+package encoder
+
+import (
+	"bytes"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+func sortTestIssues(dir string) []extract.Issue {
+	pos := func(file string, line int) token.Position {
+		return token.Position{Filename: filepath.Join(dir, file), Line: line, Column: 2}
+	}
+
+	return []extract.Issue{
+		{MsgID: "zebra", Pos: pos("b.go", 3), Comments: []string{"second"}},
+		{MsgID: "apple", Pos: pos("b.go", 1)},
+		{MsgID: "zebra", Pos: pos("a.go", 7), Comments: []string{"first"}},
+		{MsgID: "mango", Pos: pos("a.go", 9)},
+		{MsgID: "apple", Pos: pos("b.go", 1)},
+	}
+}
+
+func encodeSorted(t *testing.T, cfg *config.Config, issues []extract.Issue) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, NewPotEncoder(cfg, &buf).Encode(issues))
+	return buf.String()
+}
+
+func msgIDOrder(out string) []string {
+	var ids []string
+	for _, line := range strings.Split(out, "\n") {
+		if id, ok := strings.CutPrefix(line, "msgid "); ok && id != `""` {
+			ids = append(ids, strings.Trim(id, `"`))
+		}
+	}
+	return ids
+}
+
+func TestPotEncoderSort(t *testing.T) {
+	dir := t.TempDir()
+	newCfg := func() *config.Config {
+		cfg := config.NewDefault()
+		cfg.OutputDir = dir
+		cfg.OmitHeader = true
+		require.NoError(t, cfg.Prepare())
+		return cfg
+	}
+
+	t.Run("independent of the extraction order", func(t *testing.T) {
+		issues := sortTestIssues(dir)
+		reversed := slices.Clone(issues)
+		slices.Reverse(reversed)
+
+		out := encodeSorted(t, newCfg(), issues)
+		assert.Equal(t, out, encodeSorted(t, newCfg(), reversed))
+		assert.Contains(t, out, "#. first\n#. second\n#: a.go:7 b.go:3\nmsgid \"zebra\"")
+		assert.Contains(t, out, "#: b.go:1\nmsgid \"apple\"")
+	})
+
+	t.Run("sort output", func(t *testing.T) {
+		cfg := newCfg()
+		cfg.SortOutput = true
+		assert.Equal(t, []string{"apple", "mango", "zebra"}, msgIDOrder(encodeSorted(t, cfg, sortTestIssues(dir))))
+	})
+
+	t.Run("sort by file", func(t *testing.T) {
+		cfg := newCfg()
+		cfg.SortByFile = true
+		assert.Equal(t, []string{"zebra", "mango", "apple"}, msgIDOrder(encodeSorted(t, cfg, sortTestIssues(dir))))
+	})
+}
+
+func TestPotEncoderCreationDate(t *testing.T) {
+	cfg := config.NewDefault()
+	require.NoError(t, cfg.Prepare())
+	assert.Contains(t, encodeSorted(t, cfg, nil), "POT-Creation-Date: ")
+
+	cfg.CreationDate = config.CreationDateOmit
+	out := encodeSorted(t, cfg, nil)
+	assert.NotContains(t, out, "POT-Creation-Date")
+	assert.Contains(t, out, "PO-Revision-Date: ")
+
+	// Only the header field is removed, not the lines of a message
+	issues := []extract.Issue{{MsgID: "Header:\nPOT-Creation-Date: today\n", Pos: token.Position{Filename: filepath.Join(t.TempDir(), "a.go"), Line: 1}}}
+	out = encodeSorted(t, cfg, issues)
+	assert.Contains(t, out, "msgid \"\"\n\"Header:\\n\"\n\"POT-Creation-Date: today\\n\"\n")
+	assert.Equal(t, 1, strings.Count(out, "POT-Creation-Date"))
+}
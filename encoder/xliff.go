@@ -0,0 +1,360 @@
+package encoder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/vorlif/spreak/catalog/cldrplural"
+	"github.com/vorlif/spreak/catalog/po"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+// Namespaces and names of the XLIFF files.
+const (
+	XLIFF12Namespace              = "urn:oasis:names:tc:xliff:document:1.2"
+	XLIFF20Namespace              = "urn:oasis:names:tc:xliff:document:2.0"
+	xliffSizeRestrictionNamespace = "urn:oasis:names:tc:xliff:sizerestriction:2.0"
+
+	// XLIFF12PluralGroup and XLIFF20PluralGroup are the restype (1.2) and type (2.0) of the groups that contain
+	// the plural forms of a message. The units of a group have the ID of the group followed by "-" and the
+	// plural category, e.g. "m0123456789abcdef-one".
+	XLIFF12PluralGroup = "x-gettext-plurals"
+	XLIFF20PluralGroup = "xspreak:plural"
+
+	// Categories of the notes. In XLIFF 1.2 the category is stored in the from attribute.
+	// The note XLIFFNoteSingular of a plural group contains the msgid, because the target language
+	// may have no plural category whose unit contains it.
+	XLIFFNoteDeveloper = "developer"
+	XLIFFNoteLocation  = "location"
+	XLIFFNoteFlags     = "flags"
+	XLIFFNoteSingular  = "singular"
+
+	flagMaxLength = "max-length:"
+)
+
+type xliffEncoder struct {
+	cfg *config.Config
+	w   io.Writer
+}
+
+// NewXLIFFEncoder creates an encoder for XLIFF files of the configured version.
+// The context of a message is stored in the resname (1.2) or name (2.0) attribute, the comments,
+// references and flags are stored in notes. The max-length flag is mapped to the size restriction.
+// If a target language is configured, plural messages contain a unit for each of its plural categories.
+func NewXLIFFEncoder(cfg *config.Config, w io.Writer) Encoder {
+	return &xliffEncoder{cfg: cfg, w: w}
+}
+
+func (e *xliffEncoder) Encode(issues []extract.Issue) error {
+	messages := sortedMessages(e.cfg, buildMessages(e.cfg, issues))
+	categories := PluralCategories(e.cfg.TargetLanguage)
+
+	var doc any
+	if e.cfg.XLIFFVersion == config.XLIFFVersion12 {
+		doc = e.buildXLIFF12(messages, categories)
+	} else {
+		doc = e.buildXLIFF20(messages, categories)
+	}
+
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(e.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
+// PluralCategories returns the plural categories of a language.
+// Without a language or for unknown languages "one" and "other" are returned.
+func PluralCategories(lang string) []cldrplural.Category {
+	if tag, err := language.Parse(lang); err == nil && lang != "" {
+		if ruleSet, found := cldrplural.ForLanguage(tag); found {
+			return ruleSet.Categories
+		}
+	}
+	return []cldrplural.Category{cldrplural.One, cldrplural.Other}
+}
+
+// XLIFFUnitID returns the ID of the unit of a message.
+// The ID is derived from the context and the msgid, so it does not change when other messages are added.
+func XLIFFUnitID(context, msgID string) string {
+	sum := sha256.Sum256([]byte(context + "\x04" + msgID))
+	return "m" + hex.EncodeToString(sum[:8])
+}
+
+// XLIFFPluralUnitID returns the ID of the unit of a plural category within a plural group.
+func XLIFFPluralUnitID(groupID string, cat cldrplural.Category) string {
+	return groupID + "-" + catKey(cat)
+}
+
+// pluralSource returns the source text of a plural category.
+func pluralSource(msg *po.Message, cat cldrplural.Category) string {
+	if cat == cldrplural.One {
+		return msg.ID
+	}
+	return msg.IDPlural
+}
+
+// maxLength returns the value of the max-length flag or an empty string.
+func maxLength(msg *po.Message) string {
+	for _, flag := range msg.Comment.Flags {
+		if value, ok := strings.CutPrefix(flag, flagMaxLength); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// writtenReferences returns the references of a message that are written to the output file.
+func writtenReferences(cfg *config.Config, msg *po.Message) []*po.Reference {
+	if cfg.AddLocation == config.LocationNever {
+		return nil
+	}
+	return msg.Comment.References
+}
+
+type xliff12Document struct {
+	XMLName xml.Name    `xml:"xliff"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Version string      `xml:"version,attr"`
+	File    xliff12File `xml:"file"`
+}
+
+type xliff12File struct {
+	Original       string      `xml:"original,attr"`
+	Datatype       string      `xml:"datatype,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr,omitempty"`
+	Body           xliff12Body `xml:"body"`
+}
+
+type xliff12Body struct {
+	Items []any
+}
+
+type xliff12Group struct {
+	XMLName xml.Name      `xml:"group"`
+	ID      string        `xml:"id,attr"`
+	Resname string        `xml:"resname,attr,omitempty"`
+	Restype string        `xml:"restype,attr"`
+	Notes   []xliff12Note `xml:"note"`
+	Units   []xliff12Unit `xml:"trans-unit"`
+}
+
+type xliff12Unit struct {
+	XMLName       xml.Name              `xml:"trans-unit"`
+	ID            string                `xml:"id,attr"`
+	Resname       string                `xml:"resname,attr,omitempty"`
+	MaxWidth      string                `xml:"maxwidth,attr,omitempty"`
+	SizeUnit      string                `xml:"size-unit,attr,omitempty"`
+	Source        string                `xml:"source"`
+	ContextGroups []xliff12ContextGroup `xml:"context-group"`
+	Notes         []xliff12Note         `xml:"note"`
+}
+
+type xliff12ContextGroup struct {
+	Purpose  string           `xml:"purpose,attr"`
+	Contexts []xliff12Context `xml:"context"`
+}
+
+type xliff12Context struct {
+	Type string `xml:"context-type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type xliff12Note struct {
+	From string `xml:"from,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+func (e *xliffEncoder) buildXLIFF12(messages []*po.Message, categories []cldrplural.Category) *xliff12Document {
+	doc := &xliff12Document{
+		Xmlns:   XLIFF12Namespace,
+		Version: config.XLIFFVersion12,
+		File: xliff12File{
+			Original:       e.cfg.DefaultDomain,
+			Datatype:       "plaintext",
+			SourceLanguage: e.cfg.SourceLanguage,
+			TargetLanguage: e.cfg.TargetLanguage,
+		},
+	}
+
+	for _, msg := range messages {
+		id := XLIFFUnitID(msg.Context, msg.ID)
+		notes := e.xliff12Notes(msg)
+		var locations []xliff12ContextGroup
+		for _, ref := range writtenReferences(e.cfg, msg) {
+			group := xliff12ContextGroup{
+				Purpose:  XLIFFNoteLocation,
+				Contexts: []xliff12Context{{Type: "sourcefile", Text: ref.Path}},
+			}
+			if ref.Line > 0 {
+				group.Contexts = append(group.Contexts, xliff12Context{Type: "linenumber", Text: strconv.Itoa(ref.Line)})
+			}
+			locations = append(locations, group)
+		}
+
+		newUnit := func(id, source string) xliff12Unit {
+			unit := xliff12Unit{ID: id, Resname: msg.Context, Source: source}
+			if limit := maxLength(msg); limit != "" {
+				unit.MaxWidth, unit.SizeUnit = limit, "char"
+			}
+			return unit
+		}
+
+		if msg.IDPlural == "" {
+			unit := newUnit(id, msg.ID)
+			unit.ContextGroups = locations
+			unit.Notes = notes
+			doc.File.Body.Items = append(doc.File.Body.Items, unit)
+			continue
+		}
+
+		notes = append(notes, xliff12Note{From: XLIFFNoteSingular, Text: msg.ID})
+		group := xliff12Group{ID: id, Resname: msg.Context, Restype: XLIFF12PluralGroup, Notes: notes}
+		for _, cat := range categories {
+			unit := newUnit(XLIFFPluralUnitID(id, cat), pluralSource(msg, cat))
+			unit.ContextGroups = locations
+			group.Units = append(group.Units, unit)
+		}
+		doc.File.Body.Items = append(doc.File.Body.Items, group)
+	}
+
+	return doc
+}
+
+func (e *xliffEncoder) xliff12Notes(msg *po.Message) []xliff12Note {
+	var notes []xliff12Note
+	if msg.Comment.Extracted != "" {
+		notes = append(notes, xliff12Note{From: XLIFFNoteDeveloper, Text: msg.Comment.Extracted})
+	}
+	if len(msg.Comment.Flags) > 0 {
+		notes = append(notes, xliff12Note{From: XLIFFNoteFlags, Text: strings.Join(msg.Comment.Flags, ", ")})
+	}
+	return notes
+}
+
+type xliff20Document struct {
+	XMLName  xml.Name    `xml:"xliff"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	XmlnsSlr string      `xml:"xmlns:slr,attr,omitempty"`
+	Version  string      `xml:"version,attr"`
+	SrcLang  string      `xml:"srcLang,attr"`
+	TrgLang  string      `xml:"trgLang,attr,omitempty"`
+	File     xliff20File `xml:"file"`
+}
+
+type xliff20File struct {
+	ID       string           `xml:"id,attr"`
+	Profiles *xliff20Profiles `xml:"slr:profiles,omitempty"`
+	Items    []any
+}
+
+type xliff20Profiles struct {
+	GeneralProfile string `xml:"generalProfile,attr"`
+}
+
+type xliff20Group struct {
+	XMLName xml.Name      `xml:"group"`
+	ID      string        `xml:"id,attr"`
+	Name    string        `xml:"name,attr,omitempty"`
+	Type    string        `xml:"type,attr"`
+	Notes   *xliff20Notes `xml:"notes,omitempty"`
+	Units   []xliff20Unit `xml:"unit"`
+}
+
+type xliff20Unit struct {
+	XMLName         xml.Name       `xml:"unit"`
+	ID              string         `xml:"id,attr"`
+	Name            string         `xml:"name,attr,omitempty"`
+	SizeRestriction string         `xml:"slr:sizeRestriction,attr,omitempty"`
+	Notes           *xliff20Notes  `xml:"notes,omitempty"`
+	Segment         xliff20Segment `xml:"segment"`
+}
+
+type xliff20Notes struct {
+	Notes []xliff20Note `xml:"note"`
+}
+
+type xliff20Note struct {
+	Category string `xml:"category,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+type xliff20Segment struct {
+	Source string `xml:"source"`
+}
+
+func (e *xliffEncoder) buildXLIFF20(messages []*po.Message, categories []cldrplural.Category) *xliff20Document {
+	doc := &xliff20Document{
+		Xmlns:   XLIFF20Namespace,
+		Version: config.XLIFFVersion20,
+		SrcLang: e.cfg.SourceLanguage,
+		TrgLang: e.cfg.TargetLanguage,
+		File:    xliff20File{ID: e.cfg.DefaultDomain},
+	}
+
+	for _, msg := range messages {
+		id := XLIFFUnitID(msg.Context, msg.ID)
+		notes := e.xliff20Notes(msg)
+
+		newUnit := func(id, source string) xliff20Unit {
+			unit := xliff20Unit{ID: id, Name: msg.Context, Segment: xliff20Segment{Source: source}}
+			if limit := maxLength(msg); limit != "" {
+				unit.SizeRestriction = limit
+				doc.XmlnsSlr = xliffSizeRestrictionNamespace
+				doc.File.Profiles = &xliff20Profiles{GeneralProfile: "xliff:codepoints"}
+			}
+			return unit
+		}
+
+		if msg.IDPlural == "" {
+			unit := newUnit(id, msg.ID)
+			unit.Notes = notes
+			doc.File.Items = append(doc.File.Items, unit)
+			continue
+		}
+
+		if notes == nil {
+			notes = &xliff20Notes{}
+		}
+		notes.Notes = append(notes.Notes, xliff20Note{Category: XLIFFNoteSingular, Text: msg.ID})
+		group := xliff20Group{ID: id, Name: msg.Context, Type: XLIFF20PluralGroup, Notes: notes}
+		for _, cat := range categories {
+			group.Units = append(group.Units, newUnit(XLIFFPluralUnitID(id, cat), pluralSource(msg, cat)))
+		}
+		doc.File.Items = append(doc.File.Items, group)
+	}
+
+	return doc
+}
+
+func (e *xliffEncoder) xliff20Notes(msg *po.Message) *xliff20Notes {
+	var notes []xliff20Note
+	if msg.Comment.Extracted != "" {
+		notes = append(notes, xliff20Note{Category: XLIFFNoteDeveloper, Text: msg.Comment.Extracted})
+	}
+	for _, ref := range writtenReferences(e.cfg, msg) {
+		notes = append(notes, xliff20Note{Category: XLIFFNoteLocation, Text: referenceString(ref)})
+	}
+	if len(msg.Comment.Flags) > 0 {
+		notes = append(notes, xliff20Note{Category: XLIFFNoteFlags, Text: strings.Join(msg.Comment.Flags, ", ")})
+	}
+
+	if len(notes) == 0 {
+		return nil
+	}
+	return &xliff20Notes{Notes: notes}
+}
@@ -0,0 +1,112 @@
+package encoder
+
+import (
+	"bytes"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+func xliffTestIssues(dir string) []extract.Issue {
+	return []extract.Issue{
+		{
+			MsgID:    "Hello %s",
+			Comments: []string{"Greeting"},
+			Flags:    []string{"max-length:20"},
+			Pos:      token.Position{Filename: filepath.Join(dir, "main.go"), Line: 3},
+		},
+		{
+			Context:  "files",
+			MsgID:    "%d file",
+			PluralID: "%d files",
+			Pos:      token.Position{Filename: filepath.Join(dir, "main.go"), Line: 7},
+		},
+	}
+}
+
+func encodeXLIFF(t *testing.T, version, targetLanguage string) string {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.NewDefault()
+	cfg.OutputDir = dir
+	cfg.XLIFFVersion = version
+	cfg.TargetLanguage = targetLanguage
+	require.NoError(t, cfg.Prepare())
+
+	var buf bytes.Buffer
+	require.NoError(t, NewXLIFFEncoder(cfg, &buf).Encode(xliffTestIssues(dir)))
+	return trimLines(buf.String())
+}
+
+// trimLines removes the indentation, so that the tests do not depend on it.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestXLIFFEncoder(t *testing.T) {
+	id := XLIFFUnitID("", "Hello %s")
+	pluralID := XLIFFUnitID("files", "%d file")
+
+	t.Run("version 1.2", func(t *testing.T) {
+		out := encodeXLIFF(t, config.XLIFFVersion12, "")
+		assert.Contains(t, out, `<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">`)
+		assert.Contains(t, out, `<file original="messages" datatype="plaintext" source-language="en">`)
+		assert.Contains(t, out, `<trans-unit id="`+id+`" maxwidth="20" size-unit="char">
+<source>Hello %s</source>
+<context-group purpose="location">
+<context context-type="sourcefile">main.go</context>
+<context context-type="linenumber">3</context>
+</context-group>
+<note from="developer">Greeting</note>
+<note from="flags">go-format, max-length:20</note>
+</trans-unit>`)
+		assert.Contains(t, out, `<group id="`+pluralID+`" resname="files" restype="x-gettext-plurals">
+<note from="flags">go-format</note>
+<note from="singular">%d file</note>`)
+		assert.Contains(t, out, `<trans-unit id="`+pluralID+`-one" resname="files">
+<source>%d file</source>`)
+		assert.Contains(t, out, `<trans-unit id="`+pluralID+`-other" resname="files">
+<source>%d files</source>`)
+	})
+
+	t.Run("version 2.0 with target language", func(t *testing.T) {
+		out := encodeXLIFF(t, config.XLIFFVersion20, "pl")
+		assert.Contains(t, out, `version="2.0" srcLang="en" trgLang="pl">`)
+		assert.Contains(t, out, `<slr:profiles generalProfile="xliff:codepoints"></slr:profiles>`)
+		assert.Contains(t, out, `<unit id="`+id+`" slr:sizeRestriction="20">
+<notes>
+<note category="developer">Greeting</note>
+<note category="location">main.go:3</note>
+<note category="flags">go-format, max-length:20</note>
+</notes>
+<segment>
+<source>Hello %s</source>
+</segment>
+</unit>`)
+		assert.Contains(t, out, `<group id="`+pluralID+`" name="files" type="xspreak:plural">
+<notes>
+<note category="location">main.go:7</note>
+<note category="flags">go-format</note>
+<note category="singular">%d file</note>
+</notes>`)
+		for _, cat := range []string{"one", "few", "many", "other"} {
+			assert.Contains(t, out, `<unit id="`+pluralID+`-`+cat+`" name="files">`)
+		}
+	})
+
+	t.Run("ids do not depend on other messages", func(t *testing.T) {
+		assert.Equal(t, id, XLIFFUnitID("", "Hello %s"))
+		assert.NotEqual(t, id, XLIFFUnitID("ctx", "Hello %s"))
+	})
+}
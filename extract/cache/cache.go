@@ -0,0 +1,203 @@
+// Package cache implements an on-disk cache for the definitions and issues of Go packages.
+//
+// Each package is stored under a key which is calculated from the content of its files,
+// the keys of all imported packages, the Go version, the xspreak version and the configuration
+// values which influence the extraction. If a package or one of its dependencies changes,
+// the key changes and the package is extracted again.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+// formatVersion must be increased whenever the structure of an entry changes.
+const formatVersion = "1"
+
+// Cache stores one entry per package in a directory.
+type Cache struct {
+	dir string
+	// salt contains everything that affects all keys.
+	salt string
+	log  *log.Entry
+}
+
+// New creates a cache which stores its entries in cfg.CacheDir.
+func New(cfg *config.Config) *Cache {
+	h := sha256.New()
+	writeField(h, "xspreak-cache", formatVersion)
+	writeField(h, "go", runtime.Version())
+	writeField(h, "xspreak", cfg.Version)
+	writeField(h, "error-context", cfg.ErrorContext)
+	writeField(h, "monolingual", fmt.Sprint(cfg.TmplIsMonolingual))
+	writeField(h, "build", cfg.Build.String())
+	for _, exclude := range cfg.Excludes {
+		writeField(h, "exclude", exclude)
+	}
+	for _, pattern := range cfg.ExcludedPackages {
+		writeField(h, "exclude-package", pattern)
+	}
+	for _, kw := range cfg.Keywords {
+		writeField(h, "keyword", fmt.Sprintf("%+v", *kw))
+	}
+
+	return &Cache{
+		dir:  cfg.CacheDir,
+		salt: hex.EncodeToString(h.Sum(nil)),
+		log:  log.WithField("service", "Cache"),
+	}
+}
+
+// Keys calculates the keys of the passed packages.
+// The imports of a package that are not part of pkgs are identified by their module version.
+// If the files of a package cannot be read, no key is created for the package and
+// for all packages that import it.
+func (c *Cache) Keys(pkgs []*packages.Package) map[string]string {
+	relevant := make(map[string]*packages.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		relevant[pkg.ID] = pkg
+	}
+
+	keys := make(map[string]string, len(pkgs))
+	computed := make(map[string]bool, len(pkgs))
+	var compute func(pkg *packages.Package) string
+	compute = func(pkg *packages.Package) string {
+		if computed[pkg.ID] {
+			return keys[pkg.ID]
+		}
+		// Import cycles are not possible, but protect against endless recursion anyway.
+		computed[pkg.ID] = true
+
+		key, err := c.packageKey(pkg, relevant, compute)
+		if err != nil {
+			c.log.Debugf("No cache key for %s: %v", pkg.ID, err)
+			return ""
+		}
+
+		keys[pkg.ID] = key
+		return key
+	}
+
+	for _, pkg := range pkgs {
+		compute(pkg)
+	}
+
+	return keys
+}
+
+func (c *Cache) packageKey(pkg *packages.Package, relevant map[string]*packages.Package, compute func(*packages.Package) string) (string, error) {
+	h := sha256.New()
+	writeField(h, "salt", c.salt)
+	writeField(h, "id", pkg.ID)
+
+	files := append([]string(nil), pkg.GoFiles...)
+	sort.Strings(files)
+	for _, filename := range files {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(content)
+		writeField(h, "file", filename)
+		writeField(h, "content", hex.EncodeToString(sum[:]))
+	}
+
+	importPaths := make([]string, 0, len(pkg.Imports))
+	for path := range pkg.Imports {
+		importPaths = append(importPaths, path)
+	}
+	sort.Strings(importPaths)
+
+	for _, path := range importPaths {
+		imported := pkg.Imports[path]
+		if dep, ok := relevant[imported.ID]; ok {
+			depKey := compute(dep)
+			if depKey == "" {
+				return "", fmt.Errorf("import %s has no key", imported.ID)
+			}
+			writeField(h, "import", depKey)
+			continue
+		}
+
+		version := ""
+		if imported.Module != nil {
+			version = imported.Module.Path + "@" + imported.Module.Version
+		}
+		writeField(h, "import", imported.ID+"@"+version)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get returns the entry of a key.
+// Missing or unreadable entries are reported as not found.
+func (c *Cache) Get(key string) (*Entry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.log.WithError(err).Debug("Cache entry could not be read")
+		}
+		return nil, false
+	}
+
+	entry := &Entry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		c.log.WithError(err).Debug("Cache entry could not be decoded")
+		return nil, false
+	}
+
+	return entry, true
+}
+
+// Put saves the entry of a key.
+// The entry is written to a temporary file first, so that concurrent runs never see incomplete entries.
+func (c *Cache) Put(key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	target := c.path(key)
+	if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// writeField writes a length-prefixed value, so that different field combinations cannot produce the same hash.
+func writeField(w io.Writer, name, value string) {
+	_, _ = fmt.Fprintf(w, "%s %d %s\n", name, len(value), value)
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract/etype"
+)
+
+func newTestCache(t *testing.T, version string) *Cache {
+	cfg := config.NewDefault()
+	cfg.CacheDir = t.TempDir()
+	cfg.Version = version
+	return New(cfg)
+}
+
+func TestKeys(t *testing.T) {
+	dir := t.TempDir()
+	depFile := filepath.Join(dir, "dep.go")
+	mainFile := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(depFile, []byte("package dep"), 0o600))
+	require.NoError(t, os.WriteFile(mainFile, []byte("package main"), 0o600))
+
+	dep := &packages.Package{ID: "example.com/dep", GoFiles: []string{depFile}}
+	main := &packages.Package{
+		ID:      "example.com/main",
+		GoFiles: []string{mainFile},
+		Imports: map[string]*packages.Package{
+			"example.com/dep": dep,
+			"fmt":             {ID: "fmt"},
+		},
+	}
+	pkgs := []*packages.Package{main, dep}
+
+	c := newTestCache(t, "v1")
+	keys := c.Keys(pkgs)
+	require.Len(t, keys, 2)
+	assert.NotEqual(t, keys[dep.ID], keys[main.ID])
+	assert.Equal(t, keys, c.Keys(pkgs), "keys must be stable")
+
+	require.NoError(t, os.WriteFile(depFile, []byte("package dep // changed"), 0o600))
+	changed := c.Keys(pkgs)
+	assert.NotEqual(t, keys[dep.ID], changed[dep.ID])
+	assert.NotEqual(t, keys[main.ID], changed[main.ID], "a changed dependency must change the key")
+
+	other := newTestCache(t, "v2").Keys(pkgs)
+	assert.NotEqual(t, changed[main.ID], other[main.ID], "the xspreak version must change the key")
+
+	require.NoError(t, os.Remove(depFile))
+	assert.Empty(t, c.Keys(pkgs), "packages with unreadable files must not be cached")
+}
+
+func TestPutGet(t *testing.T) {
+	c := newTestCache(t, "v1")
+	key := "0123456789abcdef"
+
+	_, found := c.Get(key)
+	assert.False(t, found)
+
+	entry := &Entry{
+		Definitions: []Definition{{Token: etype.Singular, Path: "example.com/main", ID: "example.com/main.noop", FieldName: "msg", FieldPos: 1}},
+		Issues:      []Issue{{FromExtractor: "test", IDToken: etype.Singular, MsgID: "msgid", Pos: token.Position{Filename: "main.go", Line: 3, Column: 2}}},
+	}
+	require.NoError(t, c.Put(key, entry))
+
+	restored, found := c.Get(key)
+	require.True(t, found)
+	assert.Equal(t, entry, restored)
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"go/token"
+
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/etype"
+)
+
+// Entry contains the extraction result of a single package.
+type Entry struct {
+	Definitions []Definition `json:"definitions,omitempty"`
+	Issues      []Issue      `json:"issues,omitempty"`
+}
+
+// Definition is the serializable part of an extract.Definition.
+// The references to the AST and the type information are not stored,
+// they are only available for packages that were loaded from source.
+type Definition struct {
+	Type       extract.DefinitionType `json:"type"`
+	Token      etype.Token            `json:"token"`
+	Path       string                 `json:"path"`
+	ID         string                 `json:"id"`
+	FieldName  string                 `json:"fieldName,omitempty"`
+	IsVariadic bool                   `json:"isVariadic,omitempty"`
+	FieldPos   int                    `json:"fieldPos,omitempty"`
+}
+
+// Issue is the serializable part of an extract.Issue.
+type Issue struct {
+	FromExtractor string         `json:"extractor"`
+	IDToken       etype.Token    `json:"token"`
+	Domain        string         `json:"domain,omitempty"`
+	Context       string         `json:"context,omitempty"`
+	MsgID         string         `json:"msgid"`
+	PluralID      string         `json:"pluralId,omitempty"`
+	Comments      []string       `json:"comments,omitempty"`
+	Flags         []string       `json:"flags,omitempty"`
+	Pos           token.Position `json:"pos"`
+}
+
+func newDefinition(def *extract.Definition) Definition {
+	return Definition{
+		Type:       def.Type,
+		Token:      def.Token,
+		Path:       def.Path,
+		ID:         def.ID,
+		FieldName:  def.FieldName,
+		IsVariadic: def.IsVariadic,
+		FieldPos:   def.FieldPos,
+	}
+}
+
+func (d Definition) definition() *extract.Definition {
+	return &extract.Definition{
+		Type:       d.Type,
+		Token:      d.Token,
+		Path:       d.Path,
+		ID:         d.ID,
+		FieldName:  d.FieldName,
+		IsVariadic: d.IsVariadic,
+		FieldPos:   d.FieldPos,
+	}
+}
+
+func newIssue(iss *extract.Issue) Issue {
+	return Issue{
+		FromExtractor: iss.FromExtractor,
+		IDToken:       iss.IDToken,
+		Domain:        iss.Domain,
+		Context:       iss.Context,
+		MsgID:         iss.MsgID,
+		PluralID:      iss.PluralID,
+		Comments:      iss.Comments,
+		Flags:         iss.Flags,
+		Pos:           iss.Pos,
+	}
+}
+
+func (i Issue) issue() extract.Issue {
+	return extract.Issue{
+		FromExtractor: i.FromExtractor,
+		IDToken:       i.IDToken,
+		Domain:        i.Domain,
+		Context:       i.Context,
+		MsgID:         i.MsgID,
+		PluralID:      i.PluralID,
+		Comments:      i.Comments,
+		Flags:         i.Flags,
+		Pos:           i.Pos,
+	}
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/extract"
+)
+
+// Session connects the cache with a single extraction run.
+// Packages are either restored from the cache or tracked, so that their results can be stored after the extraction.
+type Session struct {
+	cache *Cache
+
+	// keys maps the ID of all packages to their cache key.
+	keys map[string]string
+	// tracked contains the IDs of the packages whose results are stored.
+	tracked map[string]bool
+	// files maps the Go files of the tracked packages to the package ID.
+	files map[string]string
+
+	cached []extract.Issue
+}
+
+var _ extract.IssueCache = (*Session)(nil)
+
+// NewSession creates a session for the passed packages.
+// The packages must contain all packages whose content can affect the extraction, see Keys.
+func (c *Cache) NewSession(pkgs []*packages.Package) *Session {
+	return &Session{
+		cache:   c,
+		keys:    c.Keys(pkgs),
+		tracked: make(map[string]bool),
+		files:   make(map[string]string),
+	}
+}
+
+// Restore loads the entry of a package and adds its definitions to defs.
+// It returns false if there is no entry for the package.
+func (s *Session) Restore(pkg *packages.Package, defs extract.Definitions) bool {
+	key := s.keys[pkg.ID]
+	if key == "" {
+		return false
+	}
+
+	entry, ok := s.cache.Get(key)
+	if !ok {
+		return false
+	}
+
+	for _, d := range entry.Definitions {
+		def := d.definition()
+		if _, exists := defs[def.Key()]; !exists {
+			defs[def.Key()] = make(map[string]*extract.Definition)
+		}
+		defs[def.Key()][def.FieldName] = def
+	}
+
+	for _, iss := range entry.Issues {
+		s.cached = append(s.cached, iss.issue())
+	}
+
+	return true
+}
+
+// Track marks a package for extraction. Its results are stored when Store is called.
+// Packages without a key are extracted, but not stored.
+func (s *Session) Track(pkg *packages.Package) {
+	if s.keys[pkg.ID] == "" {
+		return
+	}
+
+	s.tracked[pkg.ID] = true
+	for _, filename := range pkg.GoFiles {
+		s.files[filename] = pkg.ID
+	}
+}
+
+func (s *Session) CachedIssues() []extract.Issue {
+	return s.cached
+}
+
+func (s *Session) Store(extractCtx *extract.Context, issues []extract.Issue) error {
+	// Packages with errors may have been extracted incompletely
+	for _, pkg := range extractCtx.Packages {
+		if len(pkg.Errors) > 0 {
+			delete(s.tracked, pkg.ID)
+		}
+	}
+
+	entries := make(map[string]*Entry, len(s.tracked))
+	for id := range s.tracked {
+		entries[id] = &Entry{}
+	}
+
+	for _, fields := range extractCtx.Definitions {
+		for _, def := range fields {
+			if def.Pck == nil {
+				continue
+			}
+			if entry, ok := entries[def.Pck.ID]; ok {
+				entry.Definitions = append(entry.Definitions, newDefinition(def))
+			}
+		}
+	}
+
+	// Issues are assigned by the file in which they were found, templates files are not cached.
+	for i := range issues {
+		if entry, ok := entries[s.files[issues[i].Pos.Filename]]; ok {
+			entry.Issues = append(entry.Issues, newIssue(&issues[i]))
+		}
+	}
+
+	var firstErr error
+	for id, entry := range entries {
+		if err := s.cache.Put(s.keys[id], entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
@@ -1,5 +1,105 @@
 package extract
 
-import "go/ast"
+import (
+	"go/ast"
+	"go/token"
+)
 
-type Comments map[string]map[string]ast.CommentMap // pkg -> file -> node -> comments
+type Comments map[string]map[string]*FileComments // pkg -> file -> comments
+
+// FileComments provides constant time access to the comments of a node of a Go file.
+//
+// The comments of a node are all comments which belong to the first node of its line
+// and all child nodes of this first node. The first node of a line is the outermost
+// node which starts on the same line as the node.
+// All first nodes of a file are disjoint, so they can be searched by line and position.
+type FileComments struct {
+	// lines maps a line number to the first nodes of this line.
+	lines map[int][]*lineNode
+}
+
+type lineNode struct {
+	node     ast.Node
+	pos      token.Pos
+	end      token.Pos
+	comments []*ast.CommentGroup
+}
+
+type commentFrame struct {
+	line int
+	top  *lineNode
+}
+
+// NewFileComments collects the comments of a file in a single pass.
+// If the file has no comments, nil is returned.
+func NewFileComments(fset *token.FileSet, file *ast.File) *FileComments {
+	commentMap := ast.NewCommentMap(fset, file, file.Comments)
+	if len(commentMap) == 0 {
+		return nil
+	}
+
+	fc := &FileComments{lines: make(map[int][]*lineNode)}
+	stack := make([]commentFrame, 0, 32)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		pos := fset.Position(n.Pos())
+		if !pos.IsValid() {
+			stack = append(stack, commentFrame{})
+			return true
+		}
+
+		var top *lineNode
+		if len(stack) > 0 && stack[len(stack)-1].top != nil && stack[len(stack)-1].line == pos.Line {
+			top = stack[len(stack)-1].top
+		} else {
+			top = &lineNode{node: n, pos: n.Pos(), end: n.End()}
+			fc.lines[pos.Line] = append(fc.lines[pos.Line], top)
+		}
+		stack = append(stack, commentFrame{line: pos.Line, top: top})
+
+		// The comments also belong to the first nodes of all enclosing lines
+		if groups := commentMap[n]; len(groups) > 0 {
+			var last *lineNode
+			for i := len(stack) - 1; i >= 0; i-- {
+				if ln := stack[i].top; ln != nil && ln != last {
+					last = ln
+					for _, group := range groups {
+						ln.add(group)
+					}
+				}
+			}
+		}
+
+		return true
+	})
+
+	return fc
+}
+
+func (ln *lineNode) add(group *ast.CommentGroup) {
+	for _, existing := range ln.comments {
+		if existing == group {
+			return
+		}
+	}
+	ln.comments = append(ln.comments, group)
+}
+
+// Lookup returns the comment groups of a node which starts on the given line.
+func (fc *FileComments) Lookup(node ast.Node, line int) []*ast.CommentGroup {
+	if fc == nil {
+		return nil
+	}
+
+	for _, ln := range fc.lines[line] {
+		if ln.node == node || (ln.pos <= node.Pos() && node.End() <= ln.end) {
+			return ln.comments
+		}
+	}
+
+	return nil
+}
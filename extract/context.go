@@ -7,6 +7,8 @@ import (
 	"go/types"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/tools/go/ast/inspector"
@@ -27,6 +29,13 @@ type Context struct {
 	// It contains the packages of the scanned directory and may contain duplicates.
 	OriginalPackages []*packages.Package
 
+	// ListedPackages contains the listed packages of the scanned directory with their import graph.
+	// The packages are not parsed.
+	ListedPackages []*packages.Package
+
+	// WorkspaceModules contains the directories of the modules of the go.work file, if one is used.
+	WorkspaceModules []string
+
 	// Packages contains the packages that are of interest to us
 	//
 	// In addition include:
@@ -43,43 +52,97 @@ type Context struct {
 	Definitions Definitions
 
 	Templates []*tmpl.Template
+
+	// Cache holds the issues of packages that did not change since the last extraction.
+	// It is nil if the cache is disabled.
+	Cache IssueCache
+
+	indexOnce sync.Once
+	// identIndex maps all identifiers of Packages to their package and object.
+	identIndex map[*ast.Ident]identEntry
+	// fileSets contains the distinct file sets of Packages.
+	fileSets []*token.FileSet
 }
 
-func (c *Context) GetPosition(pos token.Pos) token.Position {
+type identEntry struct {
+	pkg *packages.Package
+	obj types.Object
+}
+
+// BuildIndex creates the lookup tables used by GetType and GetPosition.
+// It is called by the loader after all packages are loaded.
+// If it is not called, the tables are created on first use.
+func (c *Context) BuildIndex() {
+	c.indexOnce.Do(c.buildIndex)
+}
+
+func (c *Context) buildIndex() {
+	defer util.TrackTime(time.Now(), "Build index")
+
+	size := 0
 	for _, pkg := range c.Packages {
-		if position := pkg.Fset.Position(pos); position.IsValid() {
-			return position
+		if pkg.TypesInfo != nil {
+			size += len(pkg.TypesInfo.Defs) + len(pkg.TypesInfo.Uses)
 		}
 	}
 
-	return token.Position{}
-}
+	c.identIndex = make(map[*ast.Ident]identEntry, size)
+	add := func(pkg *packages.Package, ident *ast.Ident, obj types.Object) {
+		// The first package wins, as with a linear search
+		if _, ok := c.identIndex[ident]; !ok {
+			c.identIndex[ident] = identEntry{pkg: pkg, obj: obj}
+		}
+	}
 
-func (c *Context) GetType(ident *ast.Ident) (*packages.Package, types.Object) {
+	seenFileSets := make(map[*token.FileSet]bool)
 	for _, pkg := range c.Packages {
-		if pkg.Types == nil {
+		if pkg.Fset != nil && !seenFileSets[pkg.Fset] {
+			seenFileSets[pkg.Fset] = true
+			c.fileSets = append(c.fileSets, pkg.Fset)
+		}
+
+		if pkg.Types == nil || pkg.TypesInfo == nil {
 			continue
 		}
-		if obj, ok := pkg.TypesInfo.Defs[ident]; ok {
-			if obj == nil || obj.Type() == nil || obj.Pkg() == nil {
-				return nil, nil
-			}
-			return pkg, obj
+
+		for ident, obj := range pkg.TypesInfo.Defs {
+			add(pkg, ident, obj)
 		}
-		if obj, ok := pkg.TypesInfo.Uses[ident]; ok {
-			if obj == nil || obj.Type() == nil || obj.Pkg() == nil {
-				return nil, nil
-			}
-			return pkg, obj
+		for ident, obj := range pkg.TypesInfo.Uses {
+			add(pkg, ident, obj)
 		}
-		if obj, ok := pkg.TypesInfo.Implicits[ident]; ok {
-			if obj == nil || obj.Type() == nil || obj.Pkg() == nil {
-				return nil, nil
+		for node, obj := range pkg.TypesInfo.Implicits {
+			if ident, ok := node.(*ast.Ident); ok {
+				add(pkg, ident, obj)
 			}
-			return pkg, obj
 		}
 	}
-	return nil, nil
+}
+
+func (c *Context) GetPosition(pos token.Pos) token.Position {
+	c.BuildIndex()
+
+	for _, fset := range c.fileSets {
+		if position := fset.Position(pos); position.IsValid() {
+			return position
+		}
+	}
+
+	return token.Position{}
+}
+
+func (c *Context) GetType(ident *ast.Ident) (*packages.Package, types.Object) {
+	c.BuildIndex()
+
+	entry, ok := c.identIndex[ident]
+	if !ok {
+		return nil, nil
+	}
+
+	if entry.obj == nil || entry.obj.Type() == nil || entry.obj.Pkg() == nil {
+		return nil, nil
+	}
+	return entry.pkg, entry.obj
 }
 
 func (c *Context) GetLocalizeTypeToken(expr ast.Expr) etype.Token {
@@ -274,7 +337,7 @@ func (c *Context) SearchStrings(startExpr ast.Expr) []*SearchResult {
 func (c *Context) GetComments(pkg *packages.Package, node ast.Node) []string {
 	var comments []string
 
-	pkgComments, pkgHashComments := c.CommentMaps[pkg.PkgPath]
+	pkgComments, pkgHashComments := c.CommentMaps[pkg.ID]
 	if !pkgHashComments {
 		return comments
 	}
@@ -286,42 +349,9 @@ func (c *Context) GetComments(pkg *packages.Package, node ast.Node) []string {
 		return comments
 	}
 
-	visited := make(map[*ast.CommentGroup]bool)
-
-	c.Inspector.WithStack([]ast.Node{node}, func(n ast.Node, _ bool, stack []ast.Node) (proceed bool) {
-		proceed = false
-		// Search stack for our node
-		if n != node {
-			return
-		}
-
-		// Find the first node of the line
-		var topNode = node
-		for i := len(stack) - 1; i >= 0; i-- {
-			entry := stack[i]
-			entryPos := c.GetPosition(entry.Pos())
-			if !entryPos.IsValid() || entryPos.Line < pos.Line {
-				break
-			}
-
-			topNode = entry
-		}
-
-		// Search for all comments for this line
-		ast.Inspect(topNode, func(node ast.Node) bool {
-			nodeComments := fileComments[node]
-			for _, comment := range nodeComments {
-				if visited[comment] {
-					continue
-				}
-
-				visited[comment] = true
-				comments = append(comments, comment.Text())
-			}
-			return true
-		})
-		return
-	})
+	for _, group := range fileComments.Lookup(node, pos.Line) {
+		comments = append(comments, group.Text())
+	}
 
 	return comments
 }
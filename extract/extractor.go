@@ -11,3 +11,23 @@ type Extractor interface {
 	// Name returns the name of the extractor
 	Name() string
 }
+
+// ContextModifier is implemented by extractors that add information to the context
+// on which other extractors depend, e.g. inline templates.
+// The runner completes these extractors one after the other before the remaining extractors are started.
+type ContextModifier interface {
+	Extractor
+
+	// ModifiesContext is a marker method without any function.
+	ModifiesContext()
+}
+
+// IssueCache stores the issues of unchanged packages so that they do not have to be extracted again.
+type IssueCache interface {
+	// CachedIssues returns the issues of all packages which were taken from the cache.
+	CachedIssues() []Issue
+
+	// Store saves the freshly extracted issues of the packages of the context.
+	// Issues must be passed before they are processed.
+	Store(extractCtx *Context, issues []Issue) error
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"os"
 	"strconv"
 	"time"
 
@@ -21,6 +23,9 @@ func NewInlineTemplateExtractor() extract.Extractor {
 	return &inlineTemplateExtractor{}
 }
 
+// ModifiesContext marks the extractor as extract.ContextModifier, because it adds the found templates to the context.
+func (i *inlineTemplateExtractor) ModifiesContext() {}
+
 func (i *inlineTemplateExtractor) Run(_ context.Context, extractCtx *extract.Context) ([]extract.Issue, error) {
 	util.TrackTime(time.Now(), "extract inline templates")
 
@@ -29,6 +34,10 @@ func (i *inlineTemplateExtractor) Run(_ context.Context, extractCtx *extract.Con
 		return []extract.Issue{}, nil
 	}
 
+	// Literals which have already been added as a template
+	visited := make(map[ast.Node]bool)
+	sources := make(sourceCache)
+
 	extractCtx.Inspector.WithStack([]ast.Node{&ast.BasicLit{}}, func(rawNode ast.Node, push bool, stack []ast.Node) (proceed bool) {
 		proceed = true
 		if !push {
@@ -61,7 +70,6 @@ func (i *inlineTemplateExtractor) Run(_ context.Context, extractCtx *extract.Con
 		if comments == nil {
 			return
 		}
-		pos := extractCtx.GetPosition(node.Pos())
 		templateString, err := strconv.Unquote(node.Value)
 		if err != nil {
 			return
@@ -72,13 +80,51 @@ func (i *inlineTemplateExtractor) Run(_ context.Context, extractCtx *extract.Con
 				continue
 			}
 
-			template, errP := tmpl.ParseString(pos.Filename, templateString)
-			if errP != nil {
-				log.WithError(errP).WithField("pos", pos).Warn("Template could not be parsed")
-				break
+			visited[node] = true
+			addInlineTemplate(extractCtx, sources, templateString, node)
+			break
+		}
+
+		return
+	})
+
+	// Strings passed to the Parse method of text/template or html/template are templates as well.
+	extractCtx.Inspector.Nodes([]ast.Node{&ast.CallExpr{}}, func(rawNode ast.Node, push bool) (proceed bool) {
+		proceed = true
+		if !push {
+			return
+		}
+
+		node := rawNode.(*ast.CallExpr)
+		if len(node.Args) != 1 {
+			return
+		}
+
+		selector, ok := node.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return
+		}
+
+		pkg, obj := extractCtx.GetType(selector.Sel)
+		if pkg == nil || !isTemplateParseFunc(obj) {
+			return
+		}
+
+		if hasNoInlineTemplateMarker(extractCtx.GetComments(pkg, node)) {
+			return
+		}
+
+		for _, res := range extractCtx.SearchStrings(node.Args[0]) {
+			if res.Node == nil || visited[res.Node] {
+				continue
+			}
+
+			if hasNoInlineTemplateMarker(extractCtx.GetComments(pkg, res.Node)) {
+				continue
 			}
-			template.GoFilePos = pos
-			extractCtx.Templates = append(extractCtx.Templates, template)
+
+			visited[res.Node] = true
+			addInlineTemplate(extractCtx, sources, res.Raw, res.Node)
 		}
 
 		return
@@ -91,6 +137,74 @@ func (i *inlineTemplateExtractor) Name() string {
 	return "inline_template_extractor"
 }
 
+// sourceCache holds the content of Go files by their filename.
+type sourceCache map[string][]byte
+
+func (c sourceCache) get(filename string) []byte {
+	if src, ok := c[filename]; ok {
+		return src
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		log.WithError(err).Debugf("Source of %s could not be read", filename)
+	}
+	c[filename] = src
+	return src
+}
+
+func addInlineTemplate(extractCtx *extract.Context, sources sourceCache, templateString string, node ast.Node) {
+	pos := extractCtx.GetPosition(node.Pos())
+
+	// If the template is a single literal, the exact positions within the Go file can be determined.
+	if lit, ok := node.(*ast.BasicLit); ok {
+		if src := sources.get(pos.Filename); src != nil {
+			template, errP := tmpl.ParseLiteral(pos.Filename, src, pos.Offset, lit.Value)
+			if errP == nil {
+				extractCtx.Templates = append(extractCtx.Templates, template)
+				return
+			}
+			log.WithError(errP).WithField("pos", pos).Debug("Template could not be parsed as literal")
+		}
+	}
+
+	template, errP := tmpl.ParseString(pos.Filename, templateString)
+	if errP != nil {
+		log.WithError(errP).WithField("pos", pos).Warn("Template could not be parsed")
+		return
+	}
+	template.GoFilePos = pos
+	extractCtx.Templates = append(extractCtx.Templates, template)
+}
+
+// isTemplateParseFunc reports whether obj is the Parse method of a text/template or html/template Template.
+func isTemplateParseFunc(obj types.Object) bool {
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Name() != "Parse" || fn.Pkg() == nil {
+		return false
+	}
+
+	if sig, isSig := fn.Type().(*types.Signature); !isSig || sig.Recv() == nil {
+		return false
+	}
+
+	switch fn.Pkg().Path() {
+	case "text/template", "html/template":
+		return true
+	default:
+		return false
+	}
+}
+
+func hasNoInlineTemplateMarker(comments []string) bool {
+	for _, comment := range comments {
+		if util.IsNoInlineTemplate(comment) {
+			return true
+		}
+	}
+	return false
+}
+
 func extractIdent(node ast.Node) *ast.Ident {
 	switch v := node.(type) {
 	case *ast.Ident:
@@ -1,7 +1,10 @@
 package extractors
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,6 +15,7 @@ import (
 	"github.com/vorlif/xspreak/extract/loader"
 	"github.com/vorlif/xspreak/extract/runner"
 	"github.com/vorlif/xspreak/tmpl"
+	"github.com/vorlif/xspreak/tmplextractors"
 )
 
 func TestInlineExtraction(t *testing.T) {
@@ -34,5 +38,82 @@ func TestInlineExtraction(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, issues)
 
-	assert.Equal(t, 3, len(extractCtx.Templates))
+	assert.Equal(t, 5, len(extractCtx.Templates))
+}
+
+func TestInlineParseCallExtraction(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.SourceDir = testdataDir
+	cfg.Keywords = tmpl.DefaultKeywords("T", false)
+	require.NoError(t, cfg.Prepare())
+	ctx := context.Background()
+	contextLoader := loader.NewPackageLoader(cfg)
+
+	extractCtx, err := contextLoader.Load(ctx)
+	require.NoError(t, err)
+
+	_, err = NewInlineTemplateExtractor().Run(ctx, extractCtx)
+	require.NoError(t, err)
+
+	issues, err := tmplextractors.NewCommandExtractor().Run(ctx, extractCtx)
+	require.NoError(t, err)
+
+	got := collectIssueStrings(issues)
+	assert.Contains(t, got, "Parsed template")
+	assert.Contains(t, got, "Parsed html template")
+	assert.NotContains(t, got, "Not a template")
+}
+
+func TestInlineTemplatePositions(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.SourceDir = testdataDir
+	cfg.Keywords = tmpl.DefaultKeywords("T", false)
+	require.NoError(t, cfg.Prepare())
+	ctx := context.Background()
+	contextLoader := loader.NewPackageLoader(cfg)
+
+	extractCtx, err := contextLoader.Load(ctx)
+	require.NoError(t, err)
+
+	_, err = NewInlineTemplateExtractor().Run(ctx, extractCtx)
+	require.NoError(t, err)
+
+	issues, err := tmplextractors.NewCommandExtractor().Run(ctx, extractCtx)
+	require.NoError(t, err)
+
+	filename, err := filepath.Abs(filepath.Join(testdataDir, "templates.go"))
+	require.NoError(t, err)
+	src, err := os.ReadFile(filename)
+	require.NoError(t, err)
+
+	tests := []struct {
+		msgID  string
+		search string
+	}{
+		{"Hello", `"Hello"`},
+		{"Dog", `"Dog"`},
+		{"Multiline String\nwith\n  newlines", "`Multiline String"},
+		{"Parsed html template", `"Parsed html template"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msgID, func(t *testing.T) {
+			offset := bytes.Index(src, []byte(tt.search))
+			require.GreaterOrEqual(t, offset, 0)
+			lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+
+			for _, iss := range issues {
+				if iss.MsgID != tt.msgID {
+					continue
+				}
+
+				assert.Equal(t, filename, iss.Pos.Filename)
+				assert.Equal(t, bytes.Count(src[:offset], []byte("\n"))+1, iss.Pos.Line)
+				assert.Equal(t, offset-lineStart+1, iss.Pos.Column)
+				assert.Equal(t, offset, iss.Pos.Offset)
+				return
+			}
+			t.Errorf("issue %q not found", tt.msgID)
+		})
+	}
 }
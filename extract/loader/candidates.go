@@ -0,0 +1,117 @@
+package loader
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/util"
+)
+
+// selectCandidates returns the IDs of the packages that can contain strings to be extracted.
+//
+// Candidates are:
+// * the spreak packages
+// * packages that import a candidate, because only then can they use types of the localize package
+// * packages that import the errors package, if errors are extracted
+// * packages that can contain inline templates, if template keywords are configured
+func (pl *PackageLoader) selectCandidates(pkgs []*packages.Package) map[string]bool {
+	defer util.TrackTime(time.Now(), "Select candidates")
+
+	scanned := make(map[string]*packages.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		scanned[pkg.ID] = pkg
+	}
+
+	results := make(map[string]bool, len(pkgs))
+	var isCandidate func(pkg *packages.Package) bool
+	isCandidate = func(pkg *packages.Package) bool {
+		if result, ok := results[pkg.ID]; ok {
+			return result
+		}
+		// Import cycles are not possible, but protect against endless recursion anyway.
+		results[pkg.ID] = false
+
+		result := config.IsValidSpreakPackage(pkg.PkgPath) || pl.createsStrings(pkg)
+		for _, imported := range pkg.Imports {
+			if dep, ok := scanned[imported.ID]; ok && isCandidate(dep) {
+				result = true
+			}
+		}
+
+		results[pkg.ID] = result
+		return result
+	}
+
+	candidates := make(map[string]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		if isCandidate(pkg) {
+			candidates[pkg.ID] = true
+		}
+	}
+
+	if pl.config.Build.Tests {
+		removeTestDuplicates(pkgs, candidates)
+	}
+
+	pl.log.Debugf("%d of %d packages can contain strings", len(candidates), len(pkgs))
+	return candidates
+}
+
+// removeTestDuplicates removes the candidates whose files are also contained in another candidate when tests are loaded.
+// go/packages returns a package "P" and its test variant "P [P.test]", which additionally contains the _test.go files.
+// Packages recompiled for the tests of other packages and the generated test main packages are removed as well.
+func removeTestDuplicates(pkgs []*packages.Package, candidates map[string]bool) {
+	for _, pkg := range pkgs {
+		if !candidates[pkg.ID] {
+			continue
+		}
+
+		path, testOf, isVariant := splitTestVariant(pkg.ID)
+		switch {
+		case !isVariant && strings.HasSuffix(pkg.ID, ".test") && pkg.Name == "main":
+			delete(candidates, pkg.ID)
+		case !isVariant && candidates[pkg.ID+" ["+pkg.ID+".test]"]:
+			delete(candidates, pkg.ID)
+		case isVariant && path != testOf && path != testOf+"_test":
+			delete(candidates, pkg.ID)
+		}
+	}
+}
+
+// splitTestVariant splits an ID of the form "path [testOf.test]".
+func splitTestVariant(id string) (path, testOf string, ok bool) {
+	path, variant, found := strings.Cut(id, " [")
+	if !found || !strings.HasSuffix(variant, ".test]") {
+		return id, "", false
+	}
+	return path, strings.TrimSuffix(variant, ".test]"), true
+}
+
+// createsStrings reports whether a package can contain strings without using the localize package.
+func (pl *PackageLoader) createsStrings(pkg *packages.Package) bool {
+	if pl.config.ExtractErrors && pkg.Imports["errors"] != nil {
+		return true
+	}
+
+	if len(pl.config.Keywords) == 0 {
+		return false
+	}
+
+	if pkg.Imports["text/template"] != nil || pkg.Imports["html/template"] != nil {
+		return true
+	}
+
+	// Inline templates can be marked with a comment in any file
+	for _, filename := range pkg.GoFiles {
+		src, err := os.ReadFile(filename)
+		if err != nil || util.ContainsFlags(src) {
+			return true
+		}
+	}
+
+	return false
+}
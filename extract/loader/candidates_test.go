@@ -0,0 +1,81 @@
+package loader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/tmpl"
+)
+
+var candidateFiles = map[string]string{
+	"direct/direct.go": `package direct
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+`,
+	"indirect/indirect.go": `package indirect
+
+import "example.com/candidates/direct"
+
+var Title = direct.Title("indirect")
+`,
+	"plain/plain.go": `package plain
+
+const Name = "plain"
+`,
+	"failing/failing.go": `package failing
+
+import "errors"
+
+var ErrFailed = errors.New("failed")
+`,
+	"inline/inline.go": `package inline
+
+// xspreak: template
+const page = "{{ .T.Get \"inline\" }}"
+`,
+}
+
+func selectTestCandidates(t *testing.T, modify func(cfg *config.Config)) []string {
+	dir := createModule(t, "example.com/candidates", candidateFiles)
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	modify(cfg)
+	require.NoError(t, cfg.Prepare())
+
+	extractCtx, err := NewPackageLoader(cfg).Load(context.Background())
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(extractCtx.Packages))
+	for _, pkg := range extractCtx.Packages {
+		if pkg.Syntax != nil {
+			ids = append(ids, pkg.ID)
+		}
+	}
+	return ids
+}
+
+func TestSelectCandidates(t *testing.T) {
+	t.Run("localize", func(t *testing.T) {
+		ids := selectTestCandidates(t, func(cfg *config.Config) {})
+		assert.ElementsMatch(t, []string{"example.com/candidates/direct", "example.com/candidates/indirect", config.SpreakLocalizePackagePath}, ids)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		ids := selectTestCandidates(t, func(cfg *config.Config) { cfg.ExtractErrors = true })
+		assert.Contains(t, ids, "example.com/candidates/failing")
+		assert.NotContains(t, ids, "example.com/candidates/plain")
+	})
+
+	t.Run("inline templates", func(t *testing.T) {
+		ids := selectTestCandidates(t, func(cfg *config.Config) { cfg.Keywords = tmpl.DefaultKeywords("T", false) })
+		assert.Contains(t, ids, "example.com/candidates/inline")
+		assert.NotContains(t, ids, "example.com/candidates/plain")
+	})
+}
@@ -1,7 +1,6 @@
 package loader
 
 import (
-	"go/ast"
 	"time"
 
 	"golang.org/x/tools/go/packages"
@@ -24,15 +23,15 @@ func extractComments(pkgs []*packages.Package) extract.Comments {
 				continue
 			}
 
-			commentMap := ast.NewCommentMap(pkg.Fset, file, file.Comments)
-			if len(commentMap) == 0 {
+			fileComments := extract.NewFileComments(pkg.Fset, file)
+			if fileComments == nil {
 				continue
 			}
 
 			if _, hasPkg := comments[pkg.ID]; !hasPkg {
-				comments[pkg.ID] = make(map[string]ast.CommentMap)
+				comments[pkg.ID] = make(map[string]*extract.FileComments)
 			}
-			comments[pkg.ID][position.Filename] = commentMap
+			comments[pkg.ID][position.Filename] = fileComments
 		}
 	}
 
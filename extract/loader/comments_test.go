@@ -2,12 +2,15 @@ package loader
 
 import (
 	"context"
+	"go/ast"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
 
 	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
 )
 
 const testdataDir = "../../testdata/project"
@@ -25,3 +28,115 @@ func TestCommentsExtractor(t *testing.T) {
 	assert.NotNil(t, extractCtx.CommentMaps)
 	assert.NotEmpty(t, extractCtx.CommentMaps)
 }
+
+// stackGetComments is the comment lookup that walks the whole program for each node.
+// It serves as reference for the indexed lookup.
+func stackGetComments(extractCtx *extract.Context, pkg *packages.Package, node ast.Node) []string {
+	var comments []string
+
+	pos := extractCtx.GetPosition(node.Pos())
+	var file *ast.File
+	for _, f := range pkg.Syntax {
+		if pkg.Fset.Position(f.Pos()).Filename == pos.Filename {
+			file = f
+		}
+	}
+	if file == nil {
+		return comments
+	}
+	fileComments := ast.NewCommentMap(pkg.Fset, file, file.Comments)
+
+	visited := make(map[*ast.CommentGroup]bool)
+	extractCtx.Inspector.WithStack([]ast.Node{node}, func(n ast.Node, _ bool, stack []ast.Node) (proceed bool) {
+		proceed = false
+		if n != node {
+			return
+		}
+
+		var topNode = node
+		for i := len(stack) - 1; i >= 0; i-- {
+			entryPos := extractCtx.GetPosition(stack[i].Pos())
+			if !entryPos.IsValid() || entryPos.Line < pos.Line {
+				break
+			}
+			topNode = stack[i]
+		}
+
+		ast.Inspect(topNode, func(node ast.Node) bool {
+			for _, comment := range fileComments[node] {
+				if visited[comment] {
+					continue
+				}
+				visited[comment] = true
+				comments = append(comments, comment.Text())
+			}
+			return true
+		})
+		return
+	})
+
+	return comments
+}
+
+func queryNodes(extractCtx *extract.Context) map[ast.Node]*packages.Package {
+	nodes := make(map[ast.Node]*packages.Package)
+	for _, pkg := range extractCtx.Packages {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				// The stack lookup does not find nodes nested in a node of the same type,
+				// so only leaf nodes are compared.
+				switch n.(type) {
+				case *ast.BasicLit, *ast.Ident:
+					nodes[n] = pkg
+				}
+				return true
+			})
+		}
+	}
+	return nodes
+}
+
+func TestGetCommentsMatchesStackLookup(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.SourceDir = testdataDir
+	require.NoError(t, cfg.Prepare())
+
+	extractCtx, err := NewPackageLoader(cfg).Load(context.Background())
+	require.NoError(t, err)
+
+	found := 0
+	for node, pkg := range queryNodes(extractCtx) {
+		want := stackGetComments(extractCtx, pkg, node)
+		got := extractCtx.GetComments(pkg, node)
+		require.Equal(t, want, got, "%s", extractCtx.GetPosition(node.Pos()))
+		found += len(got)
+	}
+	assert.NotZero(t, found)
+}
+
+func BenchmarkGetComments(b *testing.B) {
+	cfg := config.NewDefault()
+	cfg.SourceDir = testdataDir
+	require.NoError(b, cfg.Prepare())
+
+	extractCtx, err := NewPackageLoader(cfg).Load(context.Background())
+	require.NoError(b, err)
+
+	nodes := queryNodes(extractCtx)
+
+	b.Run("stack", func(b *testing.B) {
+		for b.Loop() {
+			for node, pkg := range nodes {
+				stackGetComments(extractCtx, pkg, node)
+			}
+		}
+	})
+
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			for node, pkg := range nodes {
+				extractCtx.GetComments(pkg, node)
+			}
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package loader
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-zglob"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/util"
+)
+
+// excluder decides which Go files and template files are excluded from the extraction.
+type excluder struct {
+	sourceDir string
+	// globs are matched against the paths relative to the source directory.
+	globs []string
+	// packages match the excluded packages, all files of these packages are excluded.
+	packages []func(pkg *packages.Package) bool
+}
+
+func newExcluder(cfg *config.Config) *excluder {
+	ex := &excluder{
+		sourceDir: cfg.SourceDir,
+		globs:     make([]string, 0, len(cfg.Excludes)),
+	}
+
+	for _, glob := range cfg.Excludes {
+		ex.globs = append(ex.globs, filepath.ToSlash(filepath.Clean(glob)))
+	}
+	for _, pattern := range cfg.ExcludedPackages {
+		ex.packages = append(ex.packages, util.NewPackageMatcher(cfg.SourceDir, pattern))
+	}
+
+	return ex
+}
+
+func (ex *excluder) isEmpty() bool {
+	return len(ex.globs) == 0 && len(ex.packages) == 0
+}
+
+// excludedFiles returns the Go files of the packages that are excluded.
+func (ex *excluder) excludedFiles(pkgs []*packages.Package) map[string]bool {
+	files := make(map[string]bool)
+	if ex.isEmpty() {
+		return files
+	}
+
+	for _, pkg := range pkgs {
+		excludeAll := ex.isExcludedPackage(pkg)
+		for _, filename := range pkg.GoFiles {
+			if excludeAll || ex.isExcludedFile(filename) {
+				files[filename] = true
+			}
+		}
+	}
+
+	return files
+}
+
+func (ex *excluder) isExcludedPackage(pkg *packages.Package) bool {
+	for _, match := range ex.packages {
+		if match(pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedFile reports whether a file or one of its directories matches an exclude glob.
+// Globs without a slash are matched against the names of the file and the directories,
+// e.g. "*_gen.go" or "testdata". Other globs are matched against the path relative to the source directory,
+// absolute globs against the absolute path.
+func (ex *excluder) isExcludedFile(filename string) bool {
+	if len(ex.globs) == 0 {
+		return false
+	}
+
+	rel, err := filepath.Rel(ex.sourceDir, filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filename
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, glob := range ex.globs {
+		matchName := !strings.Contains(glob, "/")
+		start := rel
+		if filepath.IsAbs(glob) {
+			start = filepath.ToSlash(filename)
+		}
+		for path := start; path != "." && path != "/" && path != ""; path = filepath.ToSlash(filepath.Dir(path)) {
+			name := path
+			if matchName {
+				name = filepath.Base(path)
+			}
+			if matched, _ := zglob.Match(glob, name); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// removeExcludedSyntax removes the syntax of the excluded files from the packages,
+// so that no strings are extracted from them.
+func removeExcludedSyntax(pkgs []*packages.Package, excluded map[string]bool) {
+	for _, pkg := range pkgs {
+		kept := pkg.Syntax[:0]
+		for _, file := range pkg.Syntax {
+			if !excluded[pkg.Fset.Position(file.Pos()).Filename] {
+				kept = append(kept, file)
+			}
+		}
+		pkg.Syntax = kept
+	}
+}
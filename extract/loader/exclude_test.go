@@ -0,0 +1,35 @@
+package loader
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+func TestIsExcludedFile(t *testing.T) {
+	sourceDir := filepath.FromSlash("/src/project")
+	cfg := config.NewDefault()
+	cfg.SourceDir = sourceDir
+	cfg.Excludes = []string{"*_gen.go", "internal/testutil", "web/**/fixtures", filepath.FromSlash("/src/project/legacy/*.html")}
+	ex := newExcluder(cfg)
+
+	tests := map[string]bool{
+		"main.go":                       false,
+		"models_gen.go":                 true,
+		"pkg/models_gen.go":             true,
+		"internal/testutil/util.go":     true,
+		"internal/testutil/sub/util.go": true,
+		"pkg/internal/testutil/util.go": false,
+		"web/templates/fixtures/a.html": true,
+		"web/templates/page.html":       false,
+		"legacy/index.html":             true,
+		"legacy/sub/index.html":         false,
+	}
+
+	for name, want := range tests {
+		assert.Equal(t, want, ex.isExcludedFile(filepath.Join(sourceDir, filepath.FromSlash(name))), name)
+	}
+}
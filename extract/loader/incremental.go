@@ -0,0 +1,25 @@
+package loader
+
+import (
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/cache"
+)
+
+// restoreFromCache takes the definitions and issues of unchanged candidates from the cache.
+// The restored packages are removed from the candidates, so that they are not extracted again.
+// The cache keys are calculated for all scanned packages, since e.g. constants of any package can end up in a string.
+func (pl *PackageLoader) restoreFromCache(scannedPkgs []*packages.Package, candidates map[string]bool, defs extract.Definitions) *cache.Session {
+	session := cache.New(pl.config).NewSession(scannedPkgs)
+
+	total := len(candidates)
+	for _, pkg := range scannedPkgs {
+		if candidates[pkg.ID] && session.Restore(pkg, defs) {
+			delete(candidates, pkg.ID)
+		}
+	}
+	pl.log.Debugf("%d of %d packages were taken from the cache", total-len(candidates), total)
+
+	return session
+}
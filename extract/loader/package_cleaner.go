@@ -1,6 +1,8 @@
 package loader
 
 import (
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,14 +19,18 @@ type packageCleaner struct {
 	// Packages that were visited
 	visited map[string]bool
 
+	// Directories of the modules of the go.work file
+	workspaceModules []string
+
 	cleanedPackages []*packages.Package
 }
 
-func cleanPackages(originalPackages []*packages.Package) []*packages.Package {
+func cleanPackages(originalPackages []*packages.Package, workspaceModules []string) []*packages.Package {
 	pc := &packageCleaner{
-		allPackages:     originalPackages,
-		visited:         make(map[string]bool),
-		cleanedPackages: make([]*packages.Package, 0, len(originalPackages)),
+		allPackages:      originalPackages,
+		visited:          make(map[string]bool),
+		workspaceModules: workspaceModules,
+		cleanedPackages:  make([]*packages.Package, 0, len(originalPackages)),
 	}
 
 	pc.performCleanup()
@@ -68,16 +74,36 @@ func (pc *packageCleaner) collectPackages(startPck *packages.Package) {
 	}
 }
 
+// isPartOfDirectory reports whether an imported package belongs to the scanned project.
+// These are the spreak packages, the packages of the modules of the go.work file
+// and the packages below one of the loaded packages.
 func (pc *packageCleaner) isPartOfDirectory(pkg *packages.Package) bool {
 	if config.IsValidSpreakPackage(pkg.PkgPath) {
 		return true
 	}
 
+	if mod := pkg.Module; mod != nil && mod.Dir != "" && slices.Contains(pc.workspaceModules, filepath.Clean(mod.Dir)) {
+		return true
+	}
+
 	for _, src := range pc.allPackages {
-		if strings.HasPrefix(pkg.PkgPath, src.PkgPath) {
+		if isSubPackage(pkg, src) {
 			return true
 		}
 	}
 
 	return false
 }
+
+// isSubPackage reports whether pkg is src or a package below src within the same module.
+func isSubPackage(pkg, src *packages.Package) bool {
+	if pkg.PkgPath != src.PkgPath && !strings.HasPrefix(pkg.PkgPath, src.PkgPath+"/") {
+		return false
+	}
+
+	if pkg.Module != nil && src.Module != nil {
+		return pkg.Module.Path == src.Module.Path
+	}
+
+	return true
+}
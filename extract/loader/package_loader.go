@@ -16,6 +16,7 @@ import (
 
 	"github.com/vorlif/xspreak/config"
 	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/cache"
 	"github.com/vorlif/xspreak/tmpl"
 	"github.com/vorlif/xspreak/util"
 )
@@ -26,24 +27,39 @@ var loadMode = packages.NeedName |
 	packages.NeedTypes |
 	packages.NeedTypesInfo |
 	packages.NeedImports |
-	packages.NeedDeps
+	packages.NeedDeps |
+	packages.NeedModule
+
+// listMode is sufficient to read the import graph, the packages are not parsed or type checked.
+var listMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedImports |
+	packages.NeedDeps |
+	packages.NeedModule
 
 type PackageLoader struct {
-	config *config.Config
-	log    *logrus.Entry
+	config   *config.Config
+	excluder *excluder
+	log      *logrus.Entry
+
+	// workspaceResolved is set once the go.work file has been read, see workspaceModules.
+	workspaceResolved bool
+	workspaceDirs     []string
+	workspaceErr      error
 }
 
 func NewPackageLoader(cfg *config.Config) *PackageLoader {
 	return &PackageLoader{
-		config: cfg,
-		log:    logrus.WithField("service", "PackageLoader"),
+		config:   cfg,
+		excluder: newExcluder(cfg),
+		log:      logrus.WithField("service", "PackageLoader"),
 	}
 }
 
-func (pl *PackageLoader) buildArgs() []string {
+func (pl *PackageLoader) buildArgs(ctx context.Context) []string {
 	args := pl.config.Args
 	if len(args) == 0 {
-		return []string{"./..."}
+		return pl.defaultArgs(ctx)
 	}
 
 	var retArgs []string
@@ -60,43 +76,158 @@ func (pl *PackageLoader) buildArgs() []string {
 	return retArgs
 }
 
+// packagesConfig returns the configuration of go/packages for the build configuration of the loader.
+func (pl *PackageLoader) packagesConfig(ctx context.Context, mode packages.LoadMode) *packages.Config {
+	build := pl.config.Build
+	return &packages.Config{
+		Context:    ctx,
+		Mode:       mode,
+		Dir:        pl.config.SourceDir,
+		Env:        build.Env(),
+		BuildFlags: build.BuildFlags(),
+		Logf:       logrus.WithField("service", "package-loader").Debugf,
+		Tests:      build.Tests,
+	}
+}
+
+// Load loads the packages in two phases.
+// First the import graph of all packages is read without parsing the files.
+// Then only the packages that can contain strings to be extracted are parsed and type checked completely,
+// of all other packages only the declarations are checked.
+// If the cache is enabled, the results of unchanged packages are taken from the cache and these packages are not extracted.
 func (pl *PackageLoader) Load(ctx context.Context) (*extract.Context, error) {
-	pkgConf := &packages.Config{
-		Context: ctx,
-		Mode:    loadMode,
-		Dir:     pl.config.SourceDir,
-		Logf:    logrus.WithField("service", "package-loader").Debugf,
-		Tests:   false,
+	pkgConf := pl.packagesConfig(ctx, loadMode)
+
+	listConf := *pkgConf
+	listConf.Mode = listMode
+	listedPkgs, err := pl.loadAllPackages(ctx, &listConf, listPackages)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(listedPkgs) == 0 {
+		return nil, errors.New("no go files to analyze")
+	}
+
+	workspace := pl.workspace(ctx)
+	scannedPkgs := cleanPackages(listedPkgs, workspace)
+	excluded := pl.excluder.excludedFiles(scannedPkgs)
+	candidates := pl.selectCandidates(scannedPkgs)
+	defs := make(extract.Definitions, 200)
+
+	var session *cache.Session
+	if pl.config.CacheDir != "" {
+		session = pl.restoreFromCache(scannedPkgs, candidates, defs)
+	}
+
+	var originalPkgs, pkgs []*packages.Package
+	if len(candidates) > 0 {
+		originalPkgs, err = pl.loadCandidates(ctx, pkgConf, listedPkgs, scannedPkgs, candidates, excluded)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pkg := range cleanPackages(originalPkgs, workspace) {
+			if !candidates[pkg.ID] {
+				continue
+			}
+			pkgs = append(pkgs, pkg)
+			if session != nil {
+				session.Track(pkg)
+			}
+		}
+	}
+
+	extractCtx, err := pl.newContext(originalPkgs, pkgs, defs, excluded)
+	if err != nil {
+		return nil, err
+	}
+
+	extractCtx.ListedPackages = listedPkgs
+	extractCtx.WorkspaceModules = workspace
+	if session != nil {
+		extractCtx.Cache = session
+	}
+
+	return extractCtx, nil
+}
+
+// loadCandidates parses and type checks the candidates.
+// The function bodies of all other packages and of the excluded files are skipped.
+func (pl *PackageLoader) loadCandidates(ctx context.Context, pkgConf *packages.Config, listedPkgs, scannedPkgs []*packages.Package, candidates, excluded map[string]bool) ([]*packages.Package, error) {
+	stripper := newBodyStripper(listedPkgs, candidates)
+	for filename := range excluded {
+		stripper.files[filename] = true
+	}
+	srcConf := *pkgConf
+	srcConf.ParseFile = stripper.parseFile
+
+	patterns := make([]string, 0, len(candidates))
+	for _, pkg := range scannedPkgs {
+		if !candidates[pkg.ID] {
+			continue
+		}
+
+		// Packages of single files cannot be loaded by their ID
+		if pkg.ID != pkg.PkgPath {
+			return pl.loadAllPackages(ctx, &srcConf, stripper.load)
+		}
+		patterns = append(patterns, pkg.ID)
+	}
+
+	pkgs, err := stripper.load(&srcConf, patterns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%w timed out to load packages", ctx.Err())
 	}
 
-	originalPkgs, err := pl.loadPackages(ctx, pkgConf)
+	return pkgs, nil
+}
+
+// loadFunc loads the packages of the arguments.
+type loadFunc func(pkgCfg *packages.Config, args []string) ([]*packages.Package, error)
+
+// loadAllPackages loads the packages of the arguments and the additionally loaded packages of the config.
+func (pl *PackageLoader) loadAllPackages(ctx context.Context, pkgConf *packages.Config, load loadFunc) ([]*packages.Package, error) {
+	originalPkgs, err := pl.loadPackages(ctx, pkgConf, load)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load packages: %w", err)
 	}
 
 	// Add loaded packages from config to originalPkgs
 	if len(pl.config.LoadedPackages) > 0 {
-		loadedPkgs, err := loadPackagesFromDir(pkgConf, pl.config.LoadedPackages)
+		loadedPkgs, err := load(pkgConf, pl.config.LoadedPackages)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load specified packages: %w", err)
 		}
 		originalPkgs = append(originalPkgs, loadedPkgs...)
 	}
 
-	if len(originalPkgs) == 0 {
-		return nil, errors.New("no go files to analyze")
-	}
+	return originalPkgs, nil
+}
 
+// newContext creates the context for the packages to be extracted.
+// defs may already contain definitions of packages that are not extracted.
+// The definitions of the excluded files are kept, but their syntax is removed before the extraction.
+func (pl *PackageLoader) newContext(originalPkgs, pkgs []*packages.Package, defs extract.Definitions, excluded map[string]bool) (*extract.Context, error) {
 	ret := &extract.Context{
 		OriginalPackages: originalPkgs,
-		Packages:         cleanPackages(originalPkgs),
+		Packages:         pkgs,
 		Config:           pl.config,
 		Log:              pl.log,
-		Definitions:      make(extract.Definitions, 200),
+		Definitions:      defs,
 	}
 
+	ret.BuildIndex()
 	ret.Inspector = createInspector(ret.Packages)
 	extractDefinitions(ret)
+	if len(excluded) > 0 {
+		removeExcludedSyntax(ret.Packages, excluded)
+		ret.Inspector = createInspector(ret.Packages)
+	}
 	ret.CommentMaps = extractComments(ret.Packages)
 
 	templateFiles, errTmpl := pl.searchTemplate()
@@ -108,11 +239,11 @@ func (pl *PackageLoader) Load(ctx context.Context) (*extract.Context, error) {
 	return ret, nil
 }
 
-func (pl *PackageLoader) loadPackages(ctx context.Context, pkgCfg *packages.Config) ([]*packages.Package, error) {
-	args := pl.buildArgs()
+func (pl *PackageLoader) loadPackages(ctx context.Context, pkgCfg *packages.Config, load loadFunc) ([]*packages.Package, error) {
+	args := pl.buildArgs(ctx)
 	pl.log.Debugf("Built loader args are %s", args)
 
-	pkgs, err := loadPackagesFromDir(pkgCfg, args)
+	pkgs, err := load(pkgCfg, args)
 	if err != nil {
 		return nil, fmt.Errorf("%w failed to load with go/packages", err)
 	}
@@ -139,12 +270,30 @@ func (pl *PackageLoader) debugPrintLoadedPackages(pkgs []*packages.Package) {
 
 func (pl *PackageLoader) searchTemplate() ([]*tmpl.Template, error) {
 	defer util.TrackTime(time.Now(), "Template file search")
-	patterns := pl.config.TemplatePatterns
-	if len(patterns) == 0 {
-		return []*tmpl.Template{}, nil
+	foundFiles, err := pl.templateFiles()
+	if err != nil {
+		return nil, err
 	}
 
-	files := make([]*tmpl.Template, 0, len(patterns)*5)
+	files := make([]*tmpl.Template, 0, len(foundFiles))
+	for _, file := range foundFiles {
+		parsed, errP := tmpl.ParseFile(file)
+		if errP != nil {
+			logrus.WithError(errP).Warn("Template could not be parsed")
+			continue
+		}
+
+		files = append(files, parsed)
+	}
+
+	pl.log.Debugf("found %d template files", len(files))
+	return files, nil
+}
+
+// templateFiles returns the absolute paths of all files matching the template patterns.
+func (pl *PackageLoader) templateFiles() ([]string, error) {
+	patterns := pl.config.TemplatePatterns
+	files := make([]string, 0, len(patterns)*5)
 
 	for _, pattern := range patterns {
 		foundFiles, err := zglob.Glob(pattern)
@@ -152,46 +301,58 @@ func (pl *PackageLoader) searchTemplate() ([]*tmpl.Template, error) {
 			return nil, err
 		}
 		for _, file := range foundFiles {
-			pl.log.Debugf("found template file %s", file)
 			pathAbs, errAbs := filepath.Abs(file)
 			if errAbs != nil {
 				logrus.WithError(errAbs).Warn("Template could not be parsed")
 				continue
 			}
 
-			parsed, errP := tmpl.ParseFile(pathAbs)
-			if errP != nil {
-				logrus.WithError(errP).Warn("Template could not be parsed")
+			if pl.excluder.isExcludedFile(pathAbs) {
+				pl.log.Debugf("excluded template file %s", file)
 				continue
 			}
+			pl.log.Debugf("found template file %s", file)
 
-			files = append(files, parsed)
+			files = append(files, pathAbs)
 		}
 	}
 
-	pl.log.Debugf("found %d template files", len(files))
 	return files, nil
 }
 
-func createInspector(pkgs []*packages.Package) *inspector.Inspector {
-	files := make([]*ast.File, 0, 200)
-	for _, pkg := range pkgs {
-		files = append(files, pkg.Syntax...)
-	}
+// SourceFiles returns the Go files of all packages that are searched for strings and all template files.
+// The packages are only listed, not parsed.
+func (pl *PackageLoader) SourceFiles(ctx context.Context) ([]string, error) {
+	pkgConf := pl.packagesConfig(ctx, listMode)
 
-	return inspector.New(files)
-}
+	listedPkgs, err := pl.loadAllPackages(ctx, pkgConf, listPackages)
+	if err != nil {
+		return nil, err
+	}
 
-func loadPackagesFromDir(pkgCfg *packages.Config, args []string) ([]*packages.Package, error) {
-	defer util.TrackTime(time.Now(), "Loading source packages")
-	pkgs, err := packages.Load(pkgCfg, args...)
+	files, err := pl.templateFiles()
 	if err != nil {
 		return nil, err
 	}
 
-	if packages.PrintErrors(pkgs) > 0 {
-		logrus.Warn("There are files with errors, the extraction may fail")
+	scannedPkgs := cleanPackages(listedPkgs, pl.workspace(ctx))
+	excluded := pl.excluder.excludedFiles(scannedPkgs)
+	for _, pkg := range scannedPkgs {
+		for _, filename := range pkg.GoFiles {
+			if !excluded[filename] {
+				files = append(files, filename)
+			}
+		}
 	}
 
-	return pkgs, nil
+	return files, nil
+}
+
+func createInspector(pkgs []*packages.Package) *inspector.Inspector {
+	files := make([]*ast.File, 0, 200)
+	for _, pkg := range pkgs {
+		files = append(files, pkg.Syntax...)
+	}
+
+	return inspector.New(files)
 }
@@ -0,0 +1,201 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+func BenchmarkGetType(b *testing.B) {
+	b.Run("project", func(b *testing.B) {
+		benchmarkGetType(b, testdataDir)
+	})
+
+	b.Run("synthetic", func(b *testing.B) {
+		benchmarkGetType(b, createSyntheticModule(b, 150, 4))
+	})
+}
+
+func benchmarkGetType(b *testing.B, dir string) {
+	extractCtx := loadContext(b, dir)
+
+	var idents []*ast.Ident
+	for _, file := range syntaxFiles(extractCtx) {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				idents = append(idents, ident)
+			}
+			return true
+		})
+	}
+	b.Logf("%d packages, %d identifiers", len(extractCtx.Packages), len(idents))
+
+	b.Run("linear", func(b *testing.B) {
+		for b.Loop() {
+			for _, ident := range idents {
+				linearGetType(extractCtx.Packages, ident)
+				linearGetPosition(extractCtx.Packages, ident.Pos())
+			}
+		}
+	})
+
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			for _, ident := range idents {
+				extractCtx.GetType(ident)
+				extractCtx.GetPosition(ident.Pos())
+			}
+		}
+	})
+}
+
+func syntaxFiles(extractCtx *extract.Context) []*ast.File {
+	var files []*ast.File
+	for _, pkg := range extractCtx.Packages {
+		files = append(files, pkg.Syntax...)
+	}
+	return files
+}
+
+func loadContext(tb testing.TB, dir string) *extract.Context {
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	require.NoError(tb, cfg.Prepare())
+
+	extractCtx, err := NewPackageLoader(cfg).Load(context.Background())
+	require.NoError(tb, err)
+	return extractCtx
+}
+
+// createSyntheticModule creates a module with pkgCount packages, each with fileCount files.
+// Every package imports its predecessor, so that the identifiers are spread across many packages.
+func createSyntheticModule(tb testing.TB, pkgCount, fileCount int) string {
+	dir := createModule(tb, "example.com/synthetic", nil)
+
+	for p := 0; p < pkgCount; p++ {
+		pkgDir := filepath.Join(dir, fmt.Sprintf("pkg%d", p))
+		require.NoError(tb, os.MkdirAll(pkgDir, 0o755))
+
+		for f := 0; f < fileCount; f++ {
+			var src string
+			if p > 0 && f == 0 {
+				src = fmt.Sprintf("package pkg%d\n\nimport prev \"example.com/synthetic/pkg%d\"\n\nvar Prev = prev.Value0\n", p, p-1)
+			} else if f == 0 {
+				// Only packages that use spreak are type checked
+				src = "package pkg0\n\nimport \"github.com/vorlif/spreak/localize\"\n\nvar Msg localize.Singular = \"synthetic\"\n"
+			} else {
+				src = fmt.Sprintf("package pkg%d\n", p)
+			}
+
+			for i := 0; i < 20; i++ {
+				src += fmt.Sprintf(`
+type Type%[1]d_%[2]d struct {
+	Name  string
+	Count int
+}
+
+var Value%[1]d_%[2]d = Type%[1]d_%[2]d{Name: "name", Count: %[2]d}
+
+func Func%[1]d_%[2]d(in Type%[1]d_%[2]d) string {
+	out := in.Name
+	for i := 0; i < in.Count; i++ {
+		out += in.Name
+	}
+	return out
+}
+`, f, i)
+			}
+
+			if f == 0 {
+				src += "\nvar Value0 = Value0_0\n"
+			}
+
+			require.NoError(tb, os.WriteFile(filepath.Join(pkgDir, fmt.Sprintf("file%d.go", f)), []byte(src), 0o644))
+		}
+	}
+
+	return dir
+}
+
+// createModule creates a module that requires spreak and contains the passed files.
+func createModule(tb testing.TB, modulePath string, files map[string]string) string {
+	dir := tb.TempDir()
+	writeModule(tb, dir, modulePath, files)
+	return dir
+}
+
+// writeModule writes a module that depends on spreak into dir.
+func writeModule(tb testing.TB, dir, modulePath string, files map[string]string) {
+	require.NoError(tb, os.MkdirAll(dir, 0o755))
+	goMod := fmt.Sprintf("module %s\n\ngo 1.24.0\n\nrequire github.com/vorlif/spreak v1.0.0\n\nrequire golang.org/x/text v0.29.0 // indirect\n", modulePath)
+	require.NoError(tb, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644))
+
+	goSum, err := os.ReadFile(filepath.Join(testdataDir, "go.sum"))
+	require.NoError(tb, err)
+	require.NoError(tb, os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644))
+
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(tb, os.MkdirAll(filepath.Dir(filename), 0o755))
+		require.NoError(tb, os.WriteFile(filename, []byte(src), 0o644))
+	}
+}
+
+// linearGetType is the lookup without an index and serves as reference for the benchmark.
+func linearGetType(pkgs []*packages.Package, ident *ast.Ident) (*packages.Package, types.Object) {
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		if obj, ok := pkg.TypesInfo.Defs[ident]; ok {
+			return pkg, obj
+		}
+		if obj, ok := pkg.TypesInfo.Uses[ident]; ok {
+			return pkg, obj
+		}
+	}
+	return nil, nil
+}
+
+func linearGetPosition(pkgs []*packages.Package, pos token.Pos) token.Position {
+	for _, pkg := range pkgs {
+		if position := pkg.Fset.Position(pos); position.IsValid() {
+			return position
+		}
+	}
+	return token.Position{}
+}
+
+func TestIndexMatchesLinearLookup(t *testing.T) {
+	extractCtx := loadContext(t, testdataDir)
+
+	for _, file := range syntaxFiles(extractCtx) {
+		ast.Inspect(file, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+
+			wantPkg, wantObj := linearGetType(extractCtx.Packages, ident)
+			if wantObj == nil || wantObj.Type() == nil || wantObj.Pkg() == nil {
+				wantPkg, wantObj = nil, nil
+			}
+			gotPkg, gotObj := extractCtx.GetType(ident)
+			require.Equal(t, wantPkg, gotPkg)
+			require.Equal(t, wantObj, gotObj)
+			require.Equal(t, linearGetPosition(extractCtx.Packages, ident.Pos()), extractCtx.GetPosition(ident.Pos()))
+			return true
+		})
+	}
+}
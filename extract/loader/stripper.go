@@ -0,0 +1,120 @@
+package loader
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/util"
+)
+
+// bodyStripper parses the files of the packages that are not extracted without function bodies.
+// Only the declarations of these packages are needed to check the types of the extracted packages.
+type bodyStripper struct {
+	files map[string]bool
+}
+
+func newBodyStripper(listedPkgs []*packages.Package, extracted map[string]bool) *bodyStripper {
+	bs := &bodyStripper{files: make(map[string]bool)}
+	packages.Visit(listedPkgs, nil, func(pkg *packages.Package) {
+		if extracted[pkg.ID] {
+			return
+		}
+		for _, filename := range pkg.GoFiles {
+			bs.files[filename] = true
+		}
+	})
+
+	// A file can belong to an extracted and another package, e.g. with LoadedPackages
+	packages.Visit(listedPkgs, nil, func(pkg *packages.Package) {
+		if !extracted[pkg.ID] {
+			return
+		}
+		for _, filename := range pkg.GoFiles {
+			delete(bs.files, filename)
+		}
+	})
+
+	return bs
+}
+
+func (bs *bodyStripper) parseFile(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+	if !bs.files[filename] {
+		return parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments)
+	}
+
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if file == nil {
+		return nil, err
+	}
+
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			funcDecl.Body = nil
+		}
+	}
+
+	return file, err
+}
+
+// removeStrippedErrors removes the type errors caused by the missing function bodies, e.g. unused imports.
+func (bs *bodyStripper) removeStrippedErrors(pkgs []*packages.Package) {
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		stripped := len(pkg.GoFiles) > 0 && bs.files[pkg.GoFiles[0]]
+
+		kept := pkg.Errors[:0]
+		for _, pkgErr := range pkg.Errors {
+			// Packages can also contain single stripped files, e.g. excluded files
+			if pkgErr.Kind != packages.TypeError || (!stripped && !bs.files[errorFilename(pkgErr.Pos)]) {
+				kept = append(kept, pkgErr)
+			}
+		}
+		pkg.Errors = kept
+		if stripped {
+			pkg.TypeErrors = nil
+		}
+	})
+}
+
+// errorFilename returns the file name of an error position of the form "file:line:column".
+func errorFilename(pos string) string {
+	for i := 0; i < 2; i++ {
+		idx := strings.LastIndexByte(pos, ':')
+		if idx < 0 {
+			break
+		}
+		if _, err := strconv.Atoi(pos[idx+1:]); err != nil {
+			break
+		}
+		pos = pos[:idx]
+	}
+	return pos
+}
+
+func (bs *bodyStripper) load(pkgCfg *packages.Config, args []string) ([]*packages.Package, error) {
+	defer util.TrackTime(time.Now(), "Loading source packages")
+	pkgs, err := packages.Load(pkgCfg, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	bs.removeStrippedErrors(pkgs)
+	if packages.PrintErrors(pkgs) > 0 {
+		logrus.Warn("There are files with errors, the extraction may fail")
+	}
+
+	return pkgs, nil
+}
+
+// listPackages loads the files and imports of the packages.
+// Errors are reported when the packages are parsed.
+func listPackages(pkgCfg *packages.Config, args []string) ([]*packages.Package, error) {
+	defer util.TrackTime(time.Now(), "Listing source packages")
+	return packages.Load(pkgCfg, args...)
+}
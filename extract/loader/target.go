@@ -0,0 +1,58 @@
+package loader
+
+import (
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/util"
+)
+
+// TargetFiles returns the Go files of the listed packages that match one of the patterns
+// and of the packages of the project imported by them.
+// The patterns are interpreted like the package patterns of the go command,
+// relative patterns are resolved relative to the source directory.
+func TargetFiles(extractCtx *extract.Context, patterns []string) map[string]bool {
+	sourceDir := extractCtx.Config.SourceDir
+	matchers := make([]func(pkg *packages.Package) bool, 0, len(patterns))
+	for _, pattern := range patterns {
+		matchers = append(matchers, util.NewPackageMatcher(sourceDir, pattern))
+	}
+
+	var roots []*packages.Package
+	for _, pkg := range extractCtx.ListedPackages {
+		for _, match := range matchers {
+			if match(pkg) {
+				roots = append(roots, pkg)
+				break
+			}
+		}
+	}
+
+	// The imported packages are part of the target if they are scanned, i.e. belong to the project
+	scanned := make(map[string]bool)
+	for _, pkg := range cleanPackages(extractCtx.ListedPackages, extractCtx.WorkspaceModules) {
+		scanned[pkg.ID] = true
+	}
+
+	files := make(map[string]bool)
+	visited := make(map[string]bool)
+	for len(roots) > 0 {
+		pkg := roots[0]
+		roots = roots[1:]
+		if visited[pkg.ID] {
+			continue
+		}
+		visited[pkg.ID] = true
+
+		for _, filename := range pkg.GoFiles {
+			files[filename] = true
+		}
+		for _, imported := range pkg.Imports {
+			if scanned[imported.ID] && !visited[imported.ID] {
+				roots = append(roots, imported)
+			}
+		}
+	}
+
+	return files
+}
@@ -0,0 +1,63 @@
+package loader
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+var targetModuleFiles = map[string]string{
+	"shared/shared.go": `package shared
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+`,
+	"cmd/server/main.go": `package main
+
+import "example.com/targets/shared"
+
+var title = shared.Title("server")
+`,
+	"cmd/cli/main.go": `package main
+
+import "example.com/targets/shared"
+
+var title = shared.Title("cli")
+`,
+}
+
+func TestTargetFiles(t *testing.T) {
+	dir := createModule(t, "example.com/targets", targetModuleFiles)
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	require.NoError(t, cfg.Prepare())
+
+	extractCtx, err := NewPackageLoader(cfg).Load(context.Background())
+	require.NoError(t, err)
+
+	files := func(patterns ...string) []string {
+		var names []string
+		for filename := range TargetFiles(extractCtx, patterns) {
+			// The files of the spreak packages are part of every target
+			rel, errR := filepath.Rel(dir, filename)
+			if errR != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"cmd/server/main.go", "shared/shared.go"}, files("./cmd/server/..."))
+	assert.ElementsMatch(t, []string{"cmd/cli/main.go", "shared/shared.go"}, files("example.com/targets/cmd/cli"))
+	assert.ElementsMatch(t, []string{"cmd/cli/main.go", "cmd/server/main.go", "shared/shared.go"}, files("./cmd/..."))
+	assert.Empty(t, files("./web/..."))
+}
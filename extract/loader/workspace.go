@@ -0,0 +1,125 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// defaultArgs returns the patterns that are loaded if no packages are specified.
+// These are all packages of the source directory. If the source directory contains
+// the modules of a go.work file, but is not itself within a module, all packages of these modules are loaded.
+func (pl *PackageLoader) defaultArgs(ctx context.Context) []string {
+	modules, err := pl.workspaceModules(ctx)
+	if err != nil {
+		pl.log.WithError(err).Debug("Workspace could not be read")
+		return []string{"./..."}
+	}
+
+	sourceDir := pl.config.SourceDir
+	args := make([]string, 0, len(modules))
+	for _, dir := range modules {
+		if isWithin(sourceDir, dir) {
+			// The source directory is part of a module, the go command finds it by itself.
+			return []string{"./..."}
+		}
+
+		if isWithin(dir, sourceDir) {
+			args = append(args, filepath.Join(dir, "..."))
+		}
+	}
+
+	if len(args) == 0 {
+		return []string{"./..."}
+	}
+
+	return args
+}
+
+// workspace returns the directories of the modules of the go.work file.
+// If the go.work file cannot be read, nil is returned.
+func (pl *PackageLoader) workspace(ctx context.Context) []string {
+	modules, err := pl.workspaceModules(ctx)
+	if err != nil {
+		pl.log.WithError(err).Debug("Workspace could not be read")
+		return nil
+	}
+	return modules
+}
+
+// ResetWorkspace discards the modules of the go.work file, so that it is read again by the next load.
+// It must be called if the go.work file has changed.
+func (pl *PackageLoader) ResetWorkspace() {
+	pl.workspaceResolved = false
+	pl.workspaceDirs = nil
+	pl.workspaceErr = nil
+}
+
+// workspaceModules returns the absolute directories of all modules of the go.work file
+// that applies to the source directory. If no go.work file is used, nil is returned.
+// The go.work file is only read once per loader, unless ResetWorkspace is called.
+func (pl *PackageLoader) workspaceModules(ctx context.Context) ([]string, error) {
+	if pl.workspaceResolved {
+		return pl.workspaceDirs, pl.workspaceErr
+	}
+
+	modules, err := readWorkspace(ctx, pl.config.SourceDir)
+	if ctx.Err() != nil {
+		// A canceled lookup says nothing about the workspace
+		return nil, ctx.Err()
+	}
+
+	pl.workspaceResolved = true
+	pl.workspaceDirs, pl.workspaceErr = modules, err
+	return modules, err
+}
+
+// readWorkspace reads the modules of the go.work file that applies to dir.
+func readWorkspace(ctx context.Context, dir string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOWORK")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	goWork := strings.TrimSpace(string(out))
+	if goWork == "" || goWork == "off" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(goWork)
+	if err != nil {
+		return nil, err
+	}
+
+	workFile, err := modfile.ParseWork(goWork, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Dir(goWork)
+	modules := make([]string, 0, len(workFile.Use))
+	for _, use := range workFile.Use {
+		dir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		modules = append(modules, filepath.Clean(dir))
+	}
+
+	return modules, nil
+}
+
+// isWithin reports whether path is dir or a path below dir.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,146 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/config"
+)
+
+func createWorkspace(t *testing.T) string {
+	t.Helper()
+	// The go command does not accept -mod=mod in workspace mode
+	t.Setenv("GOFLAGS", "")
+
+	root := t.TempDir()
+	writeModule(t, filepath.Join(root, "a"), "example.com/a", map[string]string{
+		"a.go": `package a
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+`,
+	})
+	writeModule(t, filepath.Join(root, "b"), "example.com/b", map[string]string{
+		"b.go": `package b
+
+import "example.com/a"
+
+var Title = a.Title("from b")
+`,
+	})
+
+	goWork := "go 1.24.0\n\nuse (\n\t./a\n\t./b\n)\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.work"), []byte(goWork), 0o644))
+	return root
+}
+
+func loadedPackageIDs(t *testing.T, sourceDir string) []string {
+	t.Helper()
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = sourceDir
+	require.NoError(t, cfg.Prepare())
+
+	extractCtx, err := NewPackageLoader(cfg).Load(context.Background())
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(extractCtx.Packages))
+	for _, pkg := range extractCtx.Packages {
+		if pkg.Syntax != nil {
+			ids = append(ids, pkg.ID)
+		}
+	}
+	return ids
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	root := createWorkspace(t)
+
+	t.Run("workspace root", func(t *testing.T) {
+		ids := loadedPackageIDs(t, root)
+		assert.Contains(t, ids, "example.com/a")
+		assert.Contains(t, ids, "example.com/b")
+	})
+
+	t.Run("module of the workspace", func(t *testing.T) {
+		ids := loadedPackageIDs(t, filepath.Join(root, "b"))
+		assert.Contains(t, ids, "example.com/a", "definitions of other workspace modules must be loaded")
+		assert.Contains(t, ids, "example.com/b")
+	})
+}
+
+func TestWorkspaceResolvedOnce(t *testing.T) {
+	root := createWorkspace(t)
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = root
+	require.NoError(t, cfg.Prepare())
+	pl := NewPackageLoader(cfg)
+
+	ctx := context.Background()
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "b")}
+	modules, err := pl.workspaceModules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, modules)
+
+	goWork := "go 1.24.0\n\nuse ./a\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.work"), []byte(goWork), 0o644))
+
+	modules, err = pl.workspaceModules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, modules, "the go.work file must only be read once")
+
+	pl.ResetWorkspace()
+	modules, err = pl.workspaceModules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a")}, modules)
+}
+
+func TestLoadSubdirectory(t *testing.T) {
+	root := t.TempDir()
+	writeModule(t, root, "example.com/app", map[string]string{
+		"internal/text/text.go": `package text
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+
+var Internal localize.Singular = "internal"
+`,
+		"cmd/app/main.go": `package main
+
+import (
+	"github.com/vorlif/spreak/localize"
+
+	"example.com/app/internal/text"
+)
+
+var title = text.Title("app")
+
+var name localize.Singular = "main"
+
+func main() {}
+`,
+	})
+
+	ids := loadedPackageIDs(t, filepath.Join(root, "cmd", "app"))
+	assert.Contains(t, ids, "example.com/app/cmd/app")
+	assert.NotContains(t, ids, "example.com/app/internal/text", "packages of the module outside the source directory must not be extracted")
+}
+
+func TestIsSubPackage(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/app"}
+	src := &packages.Package{PkgPath: "example.com/app", Module: mod}
+
+	assert.True(t, isSubPackage(src, src))
+	assert.True(t, isSubPackage(&packages.Package{PkgPath: "example.com/app/sub", Module: mod}, src))
+	assert.False(t, isSubPackage(&packages.Package{PkgPath: "example.com/application"}, src))
+	assert.False(t, isSubPackage(&packages.Package{PkgPath: "example.com/app/nested", Module: &packages.Module{Path: "example.com/app/nested"}}, src))
+}
@@ -1,9 +1,13 @@
 package processors
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/vorlif/xspreak/config"
 	"github.com/vorlif/xspreak/extract"
 	"github.com/vorlif/xspreak/util"
@@ -35,6 +39,8 @@ func NewCommentCleaner(cfg *config.Config) Processor {
 func (s commentCleaner) Process(inIssues []extract.Issue) ([]extract.Issue, error) {
 	util.TrackTime(time.Now(), "Clean comments")
 	outIssues := make([]extract.Issue, 0, len(inIssues))
+	// warned prevents a warning from being repeated for each issue of the same comment
+	warned := make(map[string]bool)
 
 	for _, iss := range inIssues {
 		cleanedComments := make([]string, 0)
@@ -55,7 +61,7 @@ func (s commentCleaner) Process(inIssues []extract.Issue) ([]extract.Issue, erro
 				if s.hasTranslatorPrefix(line) {
 					isTranslatorComment = true
 				} else if strings.HasPrefix(line, flagPrefix) {
-					iss.Flags = append(iss.Flags, util.ParseFlags(line)...)
+					applyDirectives(&iss, util.ParseDirectives(line), warned)
 					isTranslatorComment = false
 					continue
 				} else if len(line) == 0 {
@@ -80,6 +86,44 @@ func (s commentCleaner) Process(inIssues []extract.Issue) ([]extract.Issue, erro
 	return outIssues, nil
 }
 
+// applyDirectives adds the flags of the directives to the issue.
+// The context and the domain are only set if the issue does not have one yet.
+func applyDirectives(iss *extract.Issue, d *util.Directives, warned map[string]bool) {
+	warn := func(msg string) {
+		key := iss.Pos.String() + msg
+		if !warned[key] {
+			warned[key] = true
+			log.WithField("position", iss.Pos.String()).Warnf("Invalid xspreak directive: %s", msg)
+		}
+	}
+
+	for _, warning := range d.Warnings {
+		warn(warning)
+	}
+
+	for _, flag := range d.Flags {
+		if !slices.Contains(iss.Flags, flag) {
+			iss.Flags = append(iss.Flags, flag)
+		}
+	}
+
+	if d.HasContext {
+		if iss.Context == "" {
+			iss.Context = d.Context
+		} else if iss.Context != d.Context {
+			warn(fmt.Sprintf("the message already has the context %q, context=%q is ignored", iss.Context, d.Context))
+		}
+	}
+
+	if d.HasDomain {
+		if iss.Domain == "" {
+			iss.Domain = d.Domain
+		} else if iss.Domain != d.Domain {
+			warn(fmt.Sprintf("the message already has the domain %q, domain=%q is ignored", iss.Domain, d.Domain))
+		}
+	}
+}
+
 func (s commentCleaner) hasTranslatorPrefix(line string) bool {
 	for _, prefix := range s.allowPrefixes {
 		if strings.HasPrefix(line, prefix) {
@@ -1 +1,33 @@
 package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+)
+
+func TestCommentCleanerDirectives(t *testing.T) {
+	issues := []extract.Issue{
+		{MsgID: "a", Comments: []string{"TRANSLATORS: Menu entry\nxspreak: context=menu, domain=admin, max-length:20"}},
+		{MsgID: "b", Context: "existing", Comments: []string{"xspreak: context=menu, no-go-format"}},
+		{MsgID: "c", Comments: []string{"xspreak: ignore"}},
+	}
+
+	res, err := NewCommentCleaner(config.NewDefault()).Process(issues)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	assert.Equal(t, "menu", res[0].Context)
+	assert.Equal(t, "admin", res[0].Domain)
+	assert.Equal(t, []string{"max-length:20"}, res[0].Flags)
+	assert.Equal(t, []string{"TRANSLATORS: Menu entry"}, res[0].Comments)
+
+	assert.Equal(t, "existing", res[1].Context, "an existing context must not be overwritten")
+	assert.Equal(t, []string{"no-go-format"}, res[1].Flags)
+
+	assert.Equal(t, []string{"ignore"}, res[2].Flags)
+}
@@ -0,0 +1,155 @@
+package processors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/etype"
+	"github.com/vorlif/xspreak/util"
+)
+
+type messageKey struct {
+	domain  string
+	context string
+	msgID   string
+}
+
+type conflictDetector struct {
+	fail          bool
+	defaultDomain string
+	log           *log.Entry
+}
+
+var _ Processor = (*conflictDetector)(nil)
+
+// NewConflictDetector creates a new processor that reports messages with the same msgid, context and domain
+// that are defined differently, i.e. with different plural forms or as key in one place and as message in another.
+// Messages without a domain belong to the default domain.
+// If fail is set, the extraction is aborted with ErrAbort after all conflicts have been reported.
+func NewConflictDetector(cfg *config.Config) Processor {
+	return &conflictDetector{
+		fail:          cfg.OnConflict == config.ConflictFail,
+		defaultDomain: cfg.DefaultDomain,
+		log:           log.WithField("service", "conflicts"),
+	}
+}
+
+func (c *conflictDetector) Name() string { return "conflict-detector" }
+
+func (c *conflictDetector) Process(issues []extract.Issue) ([]extract.Issue, error) {
+	util.TrackTime(time.Now(), "Detect conflicts")
+
+	groups := make(map[messageKey][]int)
+	for i, iss := range issues {
+		domain := iss.Domain
+		if domain == "" {
+			domain = c.defaultDomain
+		}
+		key := messageKey{domain: domain, context: iss.Context, msgID: iss.MsgID}
+		groups[key] = append(groups[key], i)
+	}
+
+	keys := make([]messageKey, 0)
+	for key, indices := range groups {
+		if len(indices) > 1 && conflictReason(issues, indices) != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.domain != b.domain {
+			return a.domain < b.domain
+		}
+		if a.msgID != b.msgID {
+			return a.msgID < b.msgID
+		}
+		return a.context < b.context
+	})
+
+	for _, key := range keys {
+		c.report(key, issues, groups[key])
+	}
+
+	if c.fail && len(keys) > 0 {
+		return issues, fmt.Errorf("%w: %d messages are defined differently", ErrAbort, len(keys))
+	}
+
+	return issues, nil
+}
+
+// conflictReason describes why the issues of a message conflict, it is empty if they do not.
+func conflictReason(issues []extract.Issue, indices []int) string {
+	first := issues[indices[0]]
+	for _, i := range indices[1:] {
+		iss := issues[i]
+		if isKeyToken(iss.IDToken) != isKeyToken(first.IDToken) {
+			return "used as key and as message"
+		}
+		if iss.PluralID != first.PluralID {
+			return "different plural forms"
+		}
+	}
+	return ""
+}
+
+func isKeyToken(tok etype.Token) bool {
+	return tok == etype.Key || tok == etype.PluralKey
+}
+
+func (c *conflictDetector) report(key messageKey, issues []extract.Issue, indices []int) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Conflicting definitions of msgid %q", key.msgID)
+	if key.context != "" {
+		fmt.Fprintf(&b, " with context %q", key.context)
+	}
+	if key.domain != c.defaultDomain {
+		fmt.Fprintf(&b, " in domain %q", key.domain)
+	}
+	fmt.Fprintf(&b, " (%s):", conflictReason(issues, indices))
+
+	seen := make(map[string]bool, len(indices))
+	for _, i := range indices {
+		iss := issues[i]
+		site := fmt.Sprintf("%s:%d", displayPath(iss.Pos.Filename), iss.Pos.Line)
+		if seen[site] {
+			continue
+		}
+		seen[site] = true
+
+		kind := "message"
+		if isKeyToken(iss.IDToken) {
+			kind = "key"
+		}
+		if iss.PluralID != "" {
+			fmt.Fprintf(&b, "\n\t%s: %s with plural %q", site, kind, iss.PluralID)
+		} else {
+			fmt.Fprintf(&b, "\n\t%s: %s without plural", site, kind)
+		}
+	}
+
+	if c.fail {
+		c.log.Error(b.String())
+	} else {
+		c.log.Warn(b.String())
+	}
+}
+
+// displayPath returns the path relative to the working directory, if it is below it.
+func displayPath(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return filename
+	}
+	if rel, errR := filepath.Rel(wd, filename); errR == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return filename
+}
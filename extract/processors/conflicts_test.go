@@ -0,0 +1,87 @@
+package processors
+
+import (
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/etype"
+)
+
+func newConflictIssue(tok etype.Token, msgID, pluralID string, line int) extract.Issue {
+	return extract.Issue{
+		IDToken:  tok,
+		MsgID:    msgID,
+		PluralID: pluralID,
+		Pos:      token.Position{Filename: "main.go", Line: line},
+	}
+}
+
+func TestConflictDetector(t *testing.T) {
+	cfg := config.NewDefault()
+
+	t.Run("no conflict", func(t *testing.T) {
+		issues := []extract.Issue{
+			newConflictIssue(etype.Singular, "apple", "", 1),
+			newConflictIssue(etype.Singular, "apple", "", 2),
+			newConflictIssue(etype.Singular, "tree", "trees", 3),
+			newConflictIssue(etype.Singular, "tree", "trees", 4),
+		}
+		issues[3].Context = "other"
+
+		cfg.OnConflict = config.ConflictFail
+		res, err := NewConflictDetector(cfg).Process(issues)
+		require.NoError(t, err)
+		assert.Equal(t, issues, res)
+	})
+
+	t.Run("different plurals", func(t *testing.T) {
+		issues := []extract.Issue{
+			newConflictIssue(etype.Singular, "tree", "trees", 1),
+			newConflictIssue(etype.Singular, "tree", "", 2),
+		}
+		assert.Equal(t, "different plural forms", conflictReason(issues, []int{0, 1}))
+
+		cfg.OnConflict = config.ConflictWarn
+		res, err := NewConflictDetector(cfg).Process(issues)
+		require.NoError(t, err)
+		assert.Equal(t, issues, res)
+
+		cfg.OnConflict = config.ConflictFail
+		_, err = NewConflictDetector(cfg).Process(issues)
+		assert.ErrorIs(t, err, ErrAbort)
+	})
+
+	t.Run("key and message", func(t *testing.T) {
+		issues := []extract.Issue{
+			newConflictIssue(etype.Key, "app.title", "", 1),
+			newConflictIssue(etype.Singular, "app.title", "", 2),
+		}
+		assert.Equal(t, "used as key and as message", conflictReason(issues, []int{0, 1}))
+
+		cfg.OnConflict = config.ConflictFail
+		_, err := NewConflictDetector(cfg).Process(issues)
+		assert.ErrorIs(t, err, ErrAbort)
+	})
+
+	t.Run("default domain", func(t *testing.T) {
+		issues := []extract.Issue{
+			newConflictIssue(etype.Singular, "tree", "trees", 1),
+			newConflictIssue(etype.Singular, "tree", "", 2),
+			newConflictIssue(etype.Singular, "tree", "", 3),
+		}
+		issues[1].Domain = cfg.DefaultDomain
+		issues[2].Domain = "other"
+
+		cfg.OnConflict = config.ConflictFail
+		_, err := NewConflictDetector(cfg).Process(issues)
+		assert.ErrorIs(t, err, ErrAbort)
+
+		_, err = NewConflictDetector(cfg).Process([]extract.Issue{issues[0], issues[2]})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,90 @@
+package processors
+
+import (
+	"time"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/util"
+)
+
+type packageRule struct {
+	config.PackageRule
+	match func(pkg *packages.Package) bool
+}
+
+type packageRules struct {
+	rules []packageRule
+	// files maps the Go files to their package.
+	files map[string]*packages.Package
+}
+
+var _ Processor = (*packageRules)(nil)
+
+// NewPackageRules creates a new processor that assigns the domain and the context of the package rules
+// to issues without a domain or context. For each, the first matching rule is used.
+// The packages are used to find the package of an issue by its file, they may contain the imported packages.
+func NewPackageRules(cfg *config.Config, pkgs []*packages.Package) Processor {
+	p := &packageRules{
+		rules: make([]packageRule, 0, len(cfg.PackageRules)),
+		files: make(map[string]*packages.Package),
+	}
+
+	for _, rule := range cfg.PackageRules {
+		p.rules = append(p.rules, packageRule{
+			PackageRule: rule,
+			match:       util.NewPackageMatcher(cfg.SourceDir, rule.Pattern),
+		})
+	}
+
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, filename := range pkg.GoFiles {
+			if _, ok := p.files[filename]; !ok {
+				p.files[filename] = pkg
+			}
+		}
+	})
+
+	return p
+}
+
+func (p *packageRules) Name() string { return "package-rules" }
+
+func (p *packageRules) Process(issues []extract.Issue) ([]extract.Issue, error) {
+	util.TrackTime(time.Now(), "Apply package rules")
+
+	for i := range issues {
+		iss := &issues[i]
+		if iss.Domain != "" && iss.Context != "" {
+			continue
+		}
+
+		pkg := p.files[iss.Pos.Filename]
+		if pkg == nil {
+			continue
+		}
+
+		setDomain, setContext := iss.Domain == "", iss.Context == ""
+		for _, rule := range p.rules {
+			if !setDomain && !setContext {
+				break
+			}
+			if !rule.match(pkg) {
+				continue
+			}
+
+			if setDomain && rule.Domain != "" {
+				iss.Domain = rule.Domain
+				setDomain = false
+			}
+			if setContext && rule.Context != "" {
+				iss.Context = rule.Context
+				setContext = false
+			}
+		}
+	}
+
+	return issues, nil
+}
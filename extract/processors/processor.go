@@ -1,9 +1,15 @@
 package processors
 
 import (
+	"errors"
+
 	"github.com/vorlif/xspreak/extract"
 )
 
+// ErrAbort is returned by processors, wrapped in another error, to abort the extraction.
+// All other errors only skip the processor.
+var ErrAbort = errors.New("extraction aborted")
+
 type Processor interface {
 	Process(issues []extract.Issue) ([]extract.Issue, error)
 	Name() string
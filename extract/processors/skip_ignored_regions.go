@@ -0,0 +1,90 @@
+package processors
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/util"
+)
+
+type skipIgnoredRegions struct {
+	// pkgs maps the Go files to the packages that contain them.
+	pkgs map[string][]*packages.Package
+	// files caches the directives of each file.
+	files map[string]*util.IgnoreDirectives
+}
+
+// NewSkipIgnoredRegions creates a new processor that skips issues in ignored files, packages and regions.
+// The directives are read from the Go and template files in which the issues were found.
+// The packages are used to find the files of the package of a Go file, they may contain the imported packages.
+func NewSkipIgnoredRegions(pkgs []*packages.Package) Processor {
+	s := &skipIgnoredRegions{pkgs: make(map[string][]*packages.Package)}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, filename := range pkg.GoFiles {
+			s.pkgs[filename] = append(s.pkgs[filename], pkg)
+		}
+	})
+	return s
+}
+
+func (s *skipIgnoredRegions) Name() string { return "skip-ignored-regions" }
+
+func (s *skipIgnoredRegions) Process(issues []extract.Issue) ([]extract.Issue, error) {
+	util.TrackTime(time.Now(), "Skip ignored regions")
+	s.files = make(map[string]*util.IgnoreDirectives)
+
+	issues = slices.DeleteFunc(issues, func(iss extract.Issue) bool {
+		filename := iss.Pos.Filename
+		if filename == "" {
+			return false
+		}
+
+		if strings.HasSuffix(filename, ".go") && s.isPackageIgnored(filename) {
+			return true
+		}
+
+		return s.directives(filename).IsIgnored(iss.Pos.Line)
+	})
+
+	return issues, nil
+}
+
+func (s *skipIgnoredRegions) directives(filename string) *util.IgnoreDirectives {
+	if d, ok := s.files[filename]; ok {
+		return d
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		log.WithError(err).Debugf("Ignore directives of %s could not be read", filename)
+		src = nil
+	}
+
+	d := util.ParseIgnoreDirectives(filename, src)
+	s.files[filename] = d
+	return d
+}
+
+// isPackageIgnored reports whether a file of the package of a Go file, typically doc.go, ignores the whole package.
+// The test files of a package only ignore the test files.
+func (s *skipIgnoredRegions) isPackageIgnored(filename string) bool {
+	isTest := strings.HasSuffix(filename, "_test.go")
+	for _, pkg := range s.pkgs[filename] {
+		for _, pkgFile := range pkg.GoFiles {
+			if !isTest && strings.HasSuffix(pkgFile, "_test.go") {
+				continue
+			}
+			if s.directives(pkgFile).Package {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package processors
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/vorlif/xspreak/extract"
+)
+
+func TestSkipIgnoredRegions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main/main.go":            "package main\n\n// xspreak: ignore-start\nvar a = T(\"a\")\n// xspreak: ignore-end\nvar b = T(\"b\")\n",
+		"main/gen.go":             "//xspreak:ignore-file\n\npackage main\n\nvar c = T(\"c\")\n",
+		"main/main_test.go":       "//xspreak:ignore-package\npackage main\n\nvar g = T(\"g\")\n",
+		"main/windows.go":         "//go:build windows\n\n//xspreak:ignore-package\npackage main\n",
+		"fixtures/doc.go":         "//xspreak:ignore-package\npackage fixtures\n",
+		"fixtures/data.go":        "package fixtures\n\nvar d = T(\"d\")\n",
+		"fixtures/export_test.go": "package fixtures_test\n\nvar h = T(\"h\")\n",
+		"page.html":               "{{/* xspreak: ignore-start */}}\n{{ .T.Get \"e\" }}\n{{/* xspreak: ignore-end */}}\n{{ .T.Get \"f\" }}\n",
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0o755))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	}
+
+	path := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+	issue := func(msgID, name string, line int) extract.Issue {
+		return extract.Issue{MsgID: msgID, Pos: token.Position{Filename: path(name), Line: line}}
+	}
+
+	// windows.go is excluded by its build constraint
+	pkgs := []*packages.Package{
+		{ID: "main", GoFiles: []string{path("main/main.go"), path("main/gen.go")}},
+		{ID: "main [main.test]", GoFiles: []string{path("main/main.go"), path("main/gen.go"), path("main/main_test.go")}},
+		{ID: "fixtures", GoFiles: []string{path("fixtures/doc.go"), path("fixtures/data.go")}},
+		{ID: "fixtures_test [fixtures.test]", GoFiles: []string{path("fixtures/export_test.go")}},
+	}
+	issues := []extract.Issue{
+		issue("a", "main/main.go", 4),
+		issue("b", "main/main.go", 6),
+		issue("c", "main/gen.go", 5),
+		issue("d", "fixtures/data.go", 3),
+		issue("g", "main/main_test.go", 4),
+		issue("h", "fixtures/export_test.go", 3),
+		issue("e", "page.html", 2),
+		issue("f", "page.html", 4),
+		{MsgID: "no position"},
+	}
+
+	res, err := NewSkipIgnoredRegions(pkgs).Process(issues)
+	require.NoError(t, err)
+
+	msgIDs := make([]string, 0, len(res))
+	for _, iss := range res {
+		msgIDs = append(msgIDs, iss.MsgID)
+	}
+	assert.Equal(t, []string{"b", "h", "f", "no position"}, msgIDs)
+}
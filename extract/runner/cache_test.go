@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/loader"
+)
+
+func runCached(t *testing.T, dir, cacheDir string) (*extract.Context, []extract.Issue) {
+	t.Helper()
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	cfg.ExtractErrors = true
+	cfg.CacheDir = cacheDir
+	cfg.Version = "test"
+	cfg.TemplatePatterns = []string{testdataTemplates + "/**/*.tmpl"}
+	require.NoError(t, cfg.Prepare())
+
+	ctx := context.Background()
+	extractCtx, err := loader.NewPackageLoader(cfg).Load(ctx)
+	require.NoError(t, err)
+
+	r, err := New(cfg, extractCtx.Packages)
+	require.NoError(t, err)
+
+	issues, err := r.Run(ctx, extractCtx, allExtractors())
+	require.NoError(t, err)
+	return extractCtx, issues
+}
+
+func fullIssueKeys(issues []extract.Issue) []string {
+	keys := make([]string, 0, len(issues))
+	for _, iss := range issues {
+		keys = append(keys, fmt.Sprintf("%s|%s|%s|%s|%s|%q|%q", iss.FromExtractor, iss.Pos, iss.Context, iss.MsgID, iss.PluralID, iss.Comments, iss.Flags))
+	}
+	return keys
+}
+
+func TestCacheReusesUnchangedPackages(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS(testdataDir)))
+	cacheDir := t.TempDir()
+
+	_, uncached := runCached(t, dir, "")
+	firstCtx, first := runCached(t, dir, cacheDir)
+	assert.NotEmpty(t, firstCtx.Packages)
+	assert.ElementsMatch(t, fullIssueKeys(uncached), fullIssueKeys(first))
+
+	secondCtx, second := runCached(t, dir, cacheDir)
+	assert.Empty(t, secondCtx.Packages, "all packages should be taken from the cache")
+	assert.ElementsMatch(t, fullIssueKeys(uncached), fullIssueKeys(second))
+
+	// The definition of sub.Func must be available, although the package sub is taken from the cache
+	src := "package main\n\nimport \"github.com/vorlif/testdata/sub\"\n\nfunc cached() {\n\tsub.Func(\"cached-msgid\", \"cached-plural\")\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cached.go"), []byte(src), 0o600))
+
+	_, uncached = runCached(t, dir, "")
+	thirdCtx, third := runCached(t, dir, cacheDir)
+	if assert.Len(t, thirdCtx.Packages, 1) {
+		assert.Equal(t, "github.com/vorlif/testdata", thirdCtx.Packages[0].ID)
+	}
+	assert.ElementsMatch(t, fullIssueKeys(uncached), fullIssueKeys(third))
+	assert.Contains(t, collectMsgIDs(third), "cached-msgid")
+}
+
+func TestCacheInvalidatesDependentPackages(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS(testdataDir)))
+	cacheDir := t.TempDir()
+
+	runCached(t, dir, cacheDir)
+
+	subFile := filepath.Join(dir, "sub", "sub.go")
+	content, err := os.ReadFile(subFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(subFile, append(content, []byte("\n// changed\n")...), 0o600))
+
+	extractCtx, issues := runCached(t, dir, cacheDir)
+	ids := make([]string, 0, len(extractCtx.Packages))
+	for _, pkg := range extractCtx.Packages {
+		ids = append(ids, pkg.ID)
+	}
+	assert.Contains(t, ids, "github.com/vorlif/testdata/sub")
+	assert.Contains(t, ids, "github.com/vorlif/testdata", "packages importing a changed package must be extracted again")
+
+	_, uncached := runCached(t, dir, "")
+	assert.ElementsMatch(t, fullIssueKeys(uncached), fullIssueKeys(issues))
+}
+
+func collectMsgIDs(issues []extract.Issue) []string {
+	ids := make([]string, 0, len(issues))
+	for _, iss := range issues {
+		ids = append(ids, iss.MsgID)
+	}
+	return ids
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract/processors"
+)
+
+var conflictFiles = map[string]string{
+	"main.go": `package main
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+
+func Count(msgID localize.Singular, plural localize.Plural, n int) string { return msgID }
+
+var title = Title("apple")
+
+var count = Count("apple", "apples", 2)
+
+func main() {}
+`,
+}
+
+var buildConflictFiles = map[string]string{
+	"main.go": `package main
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+
+func Count(msgID localize.Singular, plural localize.Plural, n int) string { return msgID }
+
+func main() {}
+`,
+	"unix.go": `//go:build !windows
+
+package main
+
+var count = Count("apple", "apples", 2)
+`,
+	"windows.go": `//go:build windows
+
+package main
+
+var title = Title("apple")
+`,
+}
+
+func detectConflicts(t *testing.T, onConflict string, dir string, builds ...config.BuildConfig) error {
+	t.Helper()
+
+	issues := runBuilds(t, dir, builds...)
+	assert.Len(t, issues, 2)
+
+	cfg := config.NewDefault()
+	cfg.OnConflict = onConflict
+	return DetectConflicts(cfg, issues)
+}
+
+func TestDetectConflicts(t *testing.T) {
+	dir := createModule(t, "example.com/conflicts", conflictFiles)
+
+	assert.NoError(t, detectConflicts(t, config.ConflictWarn, dir))
+	assert.NoError(t, detectConflicts(t, config.ConflictIgnore, dir))
+	assert.ErrorIs(t, detectConflicts(t, config.ConflictFail, dir), processors.ErrAbort)
+}
+
+func TestDetectConflictsBetweenBuilds(t *testing.T) {
+	dir := createModule(t, "example.com/conflicts", buildConflictFiles)
+
+	cfg := config.NewDefault()
+	cfg.OnConflict = config.ConflictFail
+	for _, build := range []config.BuildConfig{{GOOS: "linux"}, {GOOS: "windows"}} {
+		issues := runBuilds(t, dir, build)
+		require.Len(t, issues, 1)
+		assert.NoError(t, DetectConflicts(cfg, issues), build.GOOS)
+	}
+
+	err := detectConflicts(t, config.ConflictFail, dir, config.BuildConfig{GOOS: "linux"}, config.BuildConfig{GOOS: "windows"})
+	assert.ErrorIs(t, err, processors.ErrAbort)
+}
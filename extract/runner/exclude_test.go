@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/loader"
+)
+
+var excludeFiles = map[string]string{
+	"main.go": `package main
+
+import "example.com/exclude/internal/testutil"
+
+var label = testutil.Label("main")
+
+func main() {}
+`,
+	"title.go": `package main
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+
+var title = Title("title")
+`,
+	"title_gen.go": `package main
+
+import "fmt"
+
+var generated = Title("generated")
+
+func printGenerated() {
+	fmt.Println(Title("generated body"))
+}
+`,
+	"internal/testutil/testutil.go": `package testutil
+
+import "github.com/vorlif/spreak/localize"
+
+func Label(msgID localize.Singular) string { return msgID }
+
+var fixture = Label("fixture")
+`,
+	"templates/page.html":         `{{ .T.Get "page" }}`,
+	"templates/fixtures/old.html": `{{ .T.Get "old page" }}`,
+}
+
+func runExcluded(t *testing.T, dir string, modify func(cfg *config.Config)) []extract.Issue {
+	t.Helper()
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	cfg.TemplatePatterns = []string{filepath.Join(dir, "templates/**/*.html")}
+	modify(cfg)
+	require.NoError(t, cfg.Prepare())
+
+	ctx := context.Background()
+	extractCtx, err := loader.NewPackageLoader(cfg).Load(ctx)
+	require.NoError(t, err)
+	for _, pkg := range extractCtx.Packages {
+		assert.Empty(t, pkg.Errors, "excluded files must not cause errors")
+	}
+
+	r, err := New(cfg, extractCtx.Packages)
+	require.NoError(t, err)
+
+	issues, err := r.Run(ctx, extractCtx, allExtractors())
+	require.NoError(t, err)
+	return issues
+}
+
+func TestExclude(t *testing.T) {
+	dir := createModule(t, "example.com/exclude", excludeFiles)
+
+	t.Run("nothing excluded", func(t *testing.T) {
+		issues := runExcluded(t, dir, func(cfg *config.Config) {})
+		assert.ElementsMatch(t, []string{"main", "title", "generated", "generated body", "fixture", "page", "old page"}, collectMsgIDs(issues))
+	})
+
+	t.Run("excluded", func(t *testing.T) {
+		issues := runExcluded(t, dir, func(cfg *config.Config) {
+			cfg.Excludes = []string{"*_gen.go", "templates/fixtures"}
+			cfg.ExcludedPackages = []string{"./internal/testutil/..."}
+		})
+		// The definitions of excluded packages are still used
+		assert.ElementsMatch(t, []string{"main", "title", "page"}, collectMsgIDs(issues))
+	})
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	processors2 "github.com/vorlif/xspreak/extract/processors"
+)
+
+type issueKey struct {
+	domain   string
+	context  string
+	msgID    string
+	pluralID string
+	filename string
+	line     int
+	column   int
+}
+
+// MergeIssues merges the issues of several runs, e.g. of different build configurations.
+// An issue that was found at the same position by more than one run is only kept once.
+func MergeIssues(runs ...[]extract.Issue) []extract.Issue {
+	if len(runs) == 1 {
+		return runs[0]
+	}
+
+	size := 0
+	for _, issues := range runs {
+		size += len(issues)
+	}
+
+	seen := make(map[issueKey]bool, size)
+	merged := make([]extract.Issue, 0, size)
+	for _, issues := range runs {
+		for _, iss := range issues {
+			key := issueKey{
+				domain:   iss.Domain,
+				context:  iss.Context,
+				msgID:    iss.MsgID,
+				pluralID: iss.PluralID,
+				filename: iss.Pos.Filename,
+				line:     iss.Pos.Line,
+				column:   iss.Pos.Column,
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, iss)
+		}
+	}
+
+	return merged
+}
+
+// DetectConflicts reports messages that are defined differently in the merged issues of all runs.
+// Conflicts between build configurations are only visible after merging, so it must not run per build configuration.
+// If conflicts should fail the extraction, processors.ErrAbort is returned.
+func DetectConflicts(cfg *config.Config, issues []extract.Issue) error {
+	if cfg.OnConflict == config.ConflictIgnore {
+		return nil
+	}
+
+	_, err := processors2.NewConflictDetector(cfg).Process(issues)
+	return err
+}
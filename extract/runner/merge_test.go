@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/loader"
+)
+
+var buildFiles = map[string]string{
+	"title.go": `package builds
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+
+var Default = Title("default")
+`,
+	"windows.go": `//go:build windows
+
+package builds
+
+var Windows = Title("windows")
+`,
+	"custom.go": `//go:build custom
+
+package builds
+
+var Custom = Title("custom")
+`,
+	"title_test.go": `package builds
+
+var Test = Title("test")
+`,
+	"external_test.go": `package builds_test
+
+import "example.com/builds"
+
+var External = builds.Title("external test")
+`,
+}
+
+func runBuilds(t *testing.T, dir string, builds ...config.BuildConfig) []extract.Issue {
+	t.Helper()
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	cfg.Builds = builds
+	require.NoError(t, cfg.Prepare())
+
+	ctx := context.Background()
+	var runs [][]extract.Issue
+	for _, buildCfg := range cfg.BuildConfigs() {
+		extractCtx, err := loader.NewPackageLoader(buildCfg).Load(ctx)
+		require.NoError(t, err)
+
+		r, err := New(buildCfg, extractCtx.Packages)
+		require.NoError(t, err)
+
+		issues, err := r.Run(ctx, extractCtx, allExtractors())
+		require.NoError(t, err)
+		runs = append(runs, issues)
+	}
+
+	return MergeIssues(runs...)
+}
+
+func TestBuildConfigs(t *testing.T) {
+	dir := createModule(t, "example.com/builds", buildFiles)
+
+	t.Run("default", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"default"}, collectMsgIDs(runBuilds(t, dir)))
+	})
+
+	t.Run("tests", func(t *testing.T) {
+		// The package and its test variant contain the same files, they must only be extracted once
+		issues := runBuilds(t, dir, config.BuildConfig{Tests: true})
+		assert.ElementsMatch(t, []string{"default", "test", "external test"}, collectMsgIDs(issues))
+	})
+
+	t.Run("merged", func(t *testing.T) {
+		issues := runBuilds(t, dir,
+			config.BuildConfig{},
+			config.BuildConfig{GOOS: "windows", GOARCH: "amd64"},
+			config.BuildConfig{Tags: []string{"custom"}, Tests: true},
+		)
+		assert.ElementsMatch(t, []string{"default", "windows", "custom", "test", "external test"}, collectMsgIDs(issues))
+	})
+}
+
+func TestBuildTestsComments(t *testing.T) {
+	dir := createModule(t, "example.com/comments", map[string]string{
+		"main.go": `package main
+
+import "github.com/vorlif/spreak/localize"
+
+// TRANSLATORS: main comment
+var msg localize.Singular = "main string"
+
+func main() {}
+`,
+		"main_test.go": `package main
+
+import "github.com/vorlif/spreak/localize"
+
+// xspreak: ignore
+var ignored localize.Singular = "ignored test string"
+
+// TRANSLATORS: test comment
+var test localize.Singular = "test string"
+`,
+	})
+
+	// With tests, the comments are taken from the test variant "P [P.test]" of the package
+	issues := runBuilds(t, dir, config.BuildConfig{Tests: true})
+	comments := make(map[string][]string)
+	for _, iss := range issues {
+		comments[iss.MsgID] = iss.Comments
+	}
+	assert.Equal(t, map[string][]string{
+		"main string": {"TRANSLATORS: main comment"},
+		"test string": {"TRANSLATORS: test comment"},
+	}, comments)
+}
+
+func TestMergeIssues(t *testing.T) {
+	pos := token.Position{Filename: "main.go", Line: 3, Column: 5}
+	first := []extract.Issue{{MsgID: "a", Pos: pos}, {MsgID: "b", Pos: pos}}
+	second := []extract.Issue{{MsgID: "a", Pos: pos}, {MsgID: "a", Context: "ctx", Pos: pos}}
+	third := []extract.Issue{{MsgID: "a", Pos: token.Position{Filename: "main.go", Line: 4, Column: 5}}}
+
+	merged := MergeIssues(first, second, third)
+	keys := make([]string, 0, len(merged))
+	for _, iss := range merged {
+		keys = append(keys, fmt.Sprintf("%s|%s|%d", iss.Context, iss.MsgID, iss.Pos.Line))
+	}
+	assert.Equal(t, []string{"|a|3", "|b|3", "ctx|a|3", "|a|4"}, keys)
+}
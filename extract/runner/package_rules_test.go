@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract/loader"
+)
+
+var packageRuleFiles = map[string]string{
+	"title.go": `package rules
+
+import "github.com/vorlif/spreak/localize"
+
+func Title(msgID localize.Singular) string { return msgID }
+
+var root = Title("root")
+`,
+	"internal/admin/admin.go": `package admin
+
+import "example.com/rules"
+
+var title = rules.Title("admin")
+
+// xspreak: domain=explicit
+var explicit = rules.Title("explicit")
+`,
+	"cmd/cli/main.go": `package main
+
+import "example.com/rules"
+
+var title = rules.Title("cli")
+
+func main() {}
+`,
+}
+
+func TestPackageRules(t *testing.T) {
+	dir := createModule(t, "example.com/rules", packageRuleFiles)
+
+	cfg := config.NewDefault()
+	cfg.SourceDir = dir
+	cfg.PackageRules = []config.PackageRule{
+		{Pattern: "./internal/admin/...", Domain: "admin"},
+		{Pattern: "example.com/rules/cmd/...", Context: "cli"},
+		{Pattern: "./...", Domain: "fallback"},
+	}
+	require.NoError(t, cfg.Prepare())
+
+	ctx := context.Background()
+	extractCtx, err := loader.NewPackageLoader(cfg).Load(ctx)
+	require.NoError(t, err)
+
+	r, err := New(cfg, extractCtx.ListedPackages)
+	require.NoError(t, err)
+
+	issues, err := r.Run(ctx, extractCtx, allExtractors())
+	require.NoError(t, err)
+
+	got := make(map[string][2]string, len(issues))
+	for _, iss := range issues {
+		got[iss.MsgID] = [2]string{iss.Domain, iss.Context}
+	}
+	assert.Equal(t, map[string][2]string{
+		"root":     {"fallback", ""},
+		"admin":    {"admin", ""},
+		"explicit": {"explicit", ""},
+		"cli":      {"fallback", "cli"},
+	}, got)
+}
@@ -2,6 +2,9 @@ package runner
 
 import (
 	"context"
+	"errors"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -16,9 +19,14 @@ import (
 type Runner struct {
 	Processors []processors2.Processor
 	Log        *logrus.Entry
+
+	// Jobs is the maximum number of extractors that run concurrently.
+	Jobs int
 }
 
-func New(cfg *config.Config, _ []*packages.Package) (*Runner, error) {
+// New creates a runner with the processors of the configuration.
+// The packages are used to find the package of an issue by its file, see extract.Context.ListedPackages.
+func New(cfg *config.Config, pkgs []*packages.Package) (*Runner, error) {
 	p := []processors2.Processor{
 		processors2.NewSkipEmptyMsgID(),
 	}
@@ -29,7 +37,16 @@ func New(cfg *config.Config, _ []*packages.Package) (*Runner, error) {
 
 	p = append(p,
 		processors2.NewCommentCleaner(cfg),
+	)
+
+	// Package rules only apply if no directive has set the domain or context
+	if len(cfg.PackageRules) > 0 {
+		p = append(p, processors2.NewPackageRules(cfg, pkgs))
+	}
+
+	p = append(p,
 		processors2.NewSkipIgnore(),
+		processors2.NewSkipIgnoredRegions(pkgs),
 		processors2.NewUnprintableCheck(),
 		processors2.NewPrepareKey(),
 	)
@@ -37,6 +54,7 @@ func New(cfg *config.Config, _ []*packages.Package) (*Runner, error) {
 	ret := &Runner{
 		Processors: p,
 		Log:        logrus.WithField("service", "Runner"),
+		Jobs:       cfg.Jobs,
 	}
 
 	return ret, nil
@@ -46,20 +64,77 @@ func (r Runner) Run(ctx context.Context, extractCtx *extract.Context, extractors
 	r.Log.Debug("Start issue extracting")
 	defer util.TrackTime(time.Now(), "Extracting the issues")
 
-	issues := make([]extract.Issue, 0, 100)
-	for _, extractor := range extractors {
-		extractedIssues, err := extractor.Run(ctx, extractCtx)
-		if err != nil {
-			r.Log.Warnf("Can't run extractor %s: %v", extractor.Name(), err)
+	// The results are stored by the index of the extractor, so that the order does not depend on the scheduling.
+	results := make([][]extract.Issue, len(extractors))
+
+	// Extractors that modify the context must be finished before the others can start.
+	parallel := make([]int, 0, len(extractors))
+	for i, extractor := range extractors {
+		if _, ok := extractor.(extract.ContextModifier); ok {
+			results[i] = r.runExtractor(ctx, extractCtx, extractor)
 		} else {
-			issues = append(issues, extractedIssues...)
+			parallel = append(parallel, i)
+		}
+	}
+
+	jobs := r.Jobs
+	if jobs < 1 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for _, i := range parallel {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = r.runExtractor(ctx, extractCtx, extractors[i])
+		}(i)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	size := 0
+	for _, res := range results {
+		size += len(res)
+	}
+	issues := make([]extract.Issue, 0, size)
+	for _, res := range results {
+		issues = append(issues, res...)
+	}
+
+	if extractCtx.Cache != nil {
+		// The raw issues are stored, so that changed processors do not invalidate the cache
+		if err := extractCtx.Cache.Store(extractCtx, issues); err != nil {
+			r.Log.Warnf("Can't store the issues in the cache: %v", err)
 		}
+		issues = append(extractCtx.Cache.CachedIssues(), issues...)
+	}
+
+	return r.processIssues(issues)
+}
+
+func (r Runner) runExtractor(ctx context.Context, extractCtx *extract.Context, extractor extract.Extractor) []extract.Issue {
+	if ctx.Err() != nil {
+		return nil
 	}
 
-	return r.processIssues(issues), nil
+	extractedIssues, err := extractor.Run(ctx, extractCtx)
+	if err != nil {
+		r.Log.Warnf("Can't run extractor %s: %v", extractor.Name(), err)
+		return nil
+	}
+	return extractedIssues
 }
 
-func (r *Runner) processIssues(issues []extract.Issue) []extract.Issue {
+func (r *Runner) processIssues(issues []extract.Issue) ([]extract.Issue, error) {
 	defer util.TrackTime(time.Now(), "Process the issues")
 
 	for _, p := range r.Processors {
@@ -67,7 +142,9 @@ func (r *Runner) processIssues(issues []extract.Issue) []extract.Issue {
 		var err error
 
 		newIssues, err = p.Process(issues)
-		if err != nil {
+		if errors.Is(err, processors2.ErrAbort) {
+			return nil, err
+		} else if err != nil {
 			r.Log.Warnf("Can't process result by %s processor: %s", p.Name(), err)
 		} else {
 			issues = newIssues
@@ -78,5 +155,5 @@ func (r *Runner) processIssues(issues []extract.Issue) []extract.Issue {
 		}
 	}
 
-	return issues
+	return issues, nil
 }
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/extract"
+	"github.com/vorlif/xspreak/extract/extractors"
+	"github.com/vorlif/xspreak/extract/loader"
+	"github.com/vorlif/xspreak/tmplextractors"
+)
+
+var (
+	testdataDir       = filepath.FromSlash("../../testdata/project")
+	testdataTemplates = filepath.FromSlash("../../testdata/tmpl")
+)
+
+func allExtractors() []extract.Extractor {
+	return []extract.Extractor{
+		extractors.NewFuncCallExtractor(),
+		extractors.NewFuncReturnExtractor(),
+		extractors.NewGlobalAssignExtractor(),
+		extractors.NewSliceDefExtractor(),
+		extractors.NewMapsDefExtractor(),
+		extractors.NewStructDefExtractor(),
+		extractors.NewVariablesExtractor(),
+		extractors.NewErrorExtractor(),
+		extractors.NewInlineTemplateExtractor(),
+		tmplextractors.NewCommandExtractor(),
+	}
+}
+
+func runWithJobs(tb testing.TB, jobs int) []extract.Issue {
+	cfg := config.NewDefault()
+	cfg.SourceDir = testdataDir
+	cfg.ExtractErrors = true
+	cfg.Jobs = jobs
+	cfg.TemplatePatterns = []string{testdataTemplates + "/**/*.tmpl"}
+	require.NoError(tb, cfg.Prepare())
+
+	ctx := context.Background()
+	extractCtx, err := loader.NewPackageLoader(cfg).Load(ctx)
+	require.NoError(tb, err)
+
+	r, err := New(cfg, extractCtx.Packages)
+	require.NoError(tb, err)
+
+	issues, err := r.Run(ctx, extractCtx, allExtractors())
+	require.NoError(tb, err)
+	return issues
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	sequential := runWithJobs(t, 1)
+	require.NotEmpty(t, sequential)
+
+	parallel := runWithJobs(t, 8)
+	require.Len(t, parallel, len(sequential))
+
+	// The issues are merged in the order of the extractors
+	for i := range sequential {
+		assert.Equal(t, sequential[i].FromExtractor, parallel[i].FromExtractor)
+	}
+	assert.ElementsMatch(t, issueKeys(sequential), issueKeys(parallel))
+}
+
+func issueKeys(issues []extract.Issue) []string {
+	keys := make([]string, 0, len(issues))
+	for _, iss := range issues {
+		keys = append(keys, fmt.Sprintf("%s|%s|%s|%s", iss.FromExtractor, iss.MsgID, iss.Context, iss.Pos))
+	}
+	return keys
+}
+
+func TestContextModifierRunsFirst(t *testing.T) {
+	issues := runWithJobs(t, 8)
+
+	// The inline templates are only found if the template extractor runs after the inline template extractor.
+	var found bool
+	for _, iss := range issues {
+		if iss.MsgID == "Parsed template" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func BenchmarkRun(b *testing.B) {
+	cfg := config.NewDefault()
+	cfg.SourceDir = testdataDir
+	cfg.ExtractErrors = true
+	cfg.TemplatePatterns = []string{testdataTemplates + "/**/*.tmpl"}
+	require.NoError(b, cfg.Prepare())
+
+	ctx := context.Background()
+	extractCtx, err := loader.NewPackageLoader(cfg).Load(ctx)
+	require.NoError(b, err)
+
+	for _, jobs := range []int{1, 4} {
+		b.Run(map[int]string{1: "sequential", 4: "parallel"}[jobs], func(b *testing.B) {
+			r, errR := New(cfg, extractCtx.Packages)
+			require.NoError(b, errR)
+			r.Jobs = jobs
+
+			// Only the extractors without context modification, otherwise the templates grow with each iteration.
+			extractorsToRun := allExtractors()[:8]
+			for b.Loop() {
+				_, errR = r.Run(ctx, extractCtx, extractorsToRun)
+				require.NoError(b, errR)
+			}
+		})
+	}
+}
+
+// createModule writes a module that depends on spreak into a temporary directory.
+func createModule(t *testing.T, modulePath string, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	goMod := "module " + modulePath + "\n\ngo 1.24.0\n\nrequire github.com/vorlif/spreak v1.0.0\n\nrequire golang.org/x/text v0.29.0 // indirect\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644))
+
+	goSum, err := os.ReadFile(filepath.Join(testdataDir, "go.sum"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644))
+
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0o755))
+		require.NoError(t, os.WriteFile(filename, []byte(src), 0o644))
+	}
+	return dir
+}
@@ -0,0 +1,350 @@
+// Package importer converts translated files between the formats of spreak and the formats of other tools.
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/vorlif/spreak/catalog/cldrplural"
+	"github.com/vorlif/spreak/catalog/po"
+	"github.com/vorlif/spreak/catalog/poplural"
+
+	"github.com/vorlif/xspreak/encoder"
+)
+
+// headerUseCLDR tells spreak to use the CLDR plural rules for a PO file.
+const headerUseCLDR = "X-spreak-use-CLDR"
+
+// reEmptySecondForm matches the empty second plural form, which the po encoder always writes.
+var reEmptySecondForm = regexp.MustCompile(`(?m)^msgstr\[1\] ""\n`)
+
+// Message is a translated message, independent of the file format.
+type Message struct {
+	Context  string
+	ID       string
+	IDPlural string
+	// Translations contains the translation of each plural category.
+	// A message without plural only has a translation for the category Other.
+	Translations map[cldrplural.Category]string
+
+	Comments   []string
+	References []string
+	Flags      []string
+}
+
+// Catalog contains the translated messages of a language.
+type Catalog struct {
+	Language string
+	Messages []*Message
+}
+
+// DecodePO reads a PO file. If lang is empty, the language of the header is used.
+// The plural forms of files written for the CLDR plural rules (X-spreak-use-CLDR) or without Plural-Forms header
+// are assigned to the plural categories of the language in their order. Otherwise, the Plural-Forms expression
+// is evaluated for a number of each plural category.
+func DecodePO(data []byte, lang string) (*Catalog, error) {
+	file, err := po.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("PO file could not be decoded: %w", err)
+	}
+
+	cat := &Catalog{Language: lang}
+	if cat.Language == "" && file.Header != nil {
+		cat.Language = file.Header.Language
+	}
+	// The plural forms are only assigned if needed, so templates with a placeholder header can be read.
+	var indices map[cldrplural.Category]int
+
+	for _, byID := range file.Messages {
+		for _, poMsg := range byID {
+			if poMsg.ID == "" {
+				continue
+			}
+
+			msg := &Message{
+				Context:      poMsg.Context,
+				ID:           poMsg.ID,
+				IDPlural:     poMsg.IDPlural,
+				Translations: make(map[cldrplural.Category]string),
+			}
+			if poMsg.IDPlural == "" {
+				msg.Translations[cldrplural.Other] = poMsg.Str[0]
+			} else if hasTranslation(poMsg.Str) {
+				if indices == nil {
+					var err error
+					if indices, err = pluralIndices(file.Header, cat.Language); err != nil {
+						return nil, err
+					}
+				}
+				for c, i := range indices {
+					msg.Translations[c] = poMsg.Str[i]
+				}
+			}
+
+			if comment := poMsg.Comment; comment != nil {
+				if comment.Extracted != "" {
+					msg.Comments = strings.Split(comment.Extracted, "\n")
+				}
+				for _, ref := range comment.References {
+					msg.References = append(msg.References, formatReference(ref))
+				}
+				msg.Flags = append(msg.Flags, comment.Flags...)
+			}
+
+			cat.Messages = append(cat.Messages, msg)
+		}
+	}
+
+	cat.sort()
+	return cat, nil
+}
+
+func hasTranslation(str map[int]string) bool {
+	for _, s := range str {
+		if s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pluralSamples are the numbers that are tried to find a number of a plural category.
+var pluralSamples = func() []any {
+	samples := make([]any, 0, 1100)
+	for i := 0; i <= 1000; i++ {
+		samples = append(samples, i)
+	}
+	for i := 10_000; i <= 1_000_000_000; i *= 10 {
+		samples = append(samples, i)
+	}
+	for _, f := range []string{"0.5", "1.5", "2.5", "0.1", "1.1", "2.1", "5.5", "10.5"} {
+		samples = append(samples, f)
+	}
+	return samples
+}()
+
+// pluralIndices returns the msgstr index of each plural category of the language.
+func pluralIndices(header *po.Header, lang string) (map[cldrplural.Category]int, error) {
+	indices := make(map[cldrplural.Category]int)
+	if header == nil || header.PluralForms == "" || strings.EqualFold(header.Get(headerUseCLDR), "true") {
+		for i, c := range encoder.PluralCategories(lang) {
+			indices[c] = i
+		}
+		return indices, nil
+	}
+
+	rule, err := poplural.Parse(header.PluralForms)
+	if err != nil {
+		return nil, fmt.Errorf("plural forms %q could not be parsed: %w", header.PluralForms, err)
+	}
+
+	tag, errL := language.Parse(lang)
+	ruleSet, found := cldrplural.ForLanguage(tag)
+	if lang == "" || errL != nil || !found {
+		return nil, fmt.Errorf("the plural forms cannot be assigned without the CLDR plural rules of the language %q, set the language explicitly", lang)
+	}
+
+	for _, c := range ruleSet.Categories {
+		for _, sample := range pluralSamples {
+			if sampleCat, errE := ruleSet.Evaluate(sample); errE != nil || sampleCat != c {
+				continue
+			}
+			idx, errE := rule.Evaluate(sample)
+			if errE != nil {
+				return nil, errE
+			}
+			if idx >= 0 && idx < rule.NPlurals {
+				indices[c] = idx
+			}
+			break
+		}
+		if _, ok := indices[c]; !ok {
+			return nil, fmt.Errorf("no plural form of %q matches the plural category %s", header.PluralForms, catKey(c))
+		}
+	}
+	return indices, nil
+}
+
+// DecodeJSON reads a spreak JSON file.
+// JSON files do not contain the plural of a message, for plural messages the key is used as plural.
+func DecodeJSON(data []byte, lang string) (*Catalog, error) {
+	var file encoder.JSONFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("JSON file could not be decoded: %w", err)
+	}
+
+	cat := &Catalog{Language: lang}
+	for _, item := range file {
+		msg := &Message{
+			Context:      item.Message["context"],
+			ID:           item.Key,
+			Translations: make(map[cldrplural.Category]string),
+		}
+		if msg.Context != "" {
+			msg.ID = strings.TrimSuffix(item.Key, "_"+msg.Context)
+		}
+
+		for key, text := range item.Message {
+			c, ok := parseCategory(key)
+			if !ok {
+				continue
+			}
+			msg.Translations[c] = text
+			if c != cldrplural.Other {
+				msg.IDPlural = msg.ID
+			}
+		}
+
+		cat.Messages = append(cat.Messages, msg)
+	}
+
+	cat.sort()
+	return cat, nil
+}
+
+// sort sorts the messages by context and ID.
+func (c *Catalog) sort() {
+	sort.Slice(c.Messages, func(i, j int) bool {
+		if c.Messages[i].Context != c.Messages[j].Context {
+			return c.Messages[i].Context < c.Messages[j].Context
+		}
+		return c.Messages[i].ID < c.Messages[j].ID
+	})
+}
+
+// EncodePO writes the catalog as PO file.
+// The plural forms are ordered like the plural categories of the language, a plural message has one form
+// per category, and the header tells spreak to use the CLDR plural rules.
+func EncodePO(cat *Catalog, w io.Writer) error {
+	categories := encoder.PluralCategories(cat.Language)
+
+	file := &po.File{
+		Header: &po.Header{
+			Language:                cat.Language,
+			MimeVersion:             "1.0",
+			ContentType:             "text/plain; charset=UTF-8",
+			ContentTransferEncoding: "8bit",
+			UnknownFields:           map[string]string{headerUseCLDR: "true"},
+		},
+		Messages: make(po.Messages),
+	}
+
+	for _, msg := range cat.Messages {
+		poMsg := &po.Message{
+			Comment: &po.Comment{
+				Extracted: strings.Join(msg.Comments, "\n"),
+				Flags:     msg.Flags,
+			},
+			Context:  msg.Context,
+			ID:       msg.ID,
+			IDPlural: msg.IDPlural,
+			Str:      make(map[int]string),
+		}
+		for _, ref := range msg.References {
+			poMsg.Comment.References = append(poMsg.Comment.References, parseReference(ref))
+		}
+
+		if msg.IDPlural == "" {
+			poMsg.Str[0] = msg.Translations[cldrplural.Other]
+		} else {
+			for i, c := range categories {
+				poMsg.Str[i] = msg.Translations[c]
+			}
+		}
+
+		file.AddMessage(poMsg)
+	}
+
+	if len(categories) > 1 {
+		return po.NewEncoder(w).Encode(file)
+	}
+
+	// The po encoder writes at least two plural forms, but the language has only one
+	var buf bytes.Buffer
+	if err := po.NewEncoder(&buf).Encode(file); err != nil {
+		return err
+	}
+	_, err := w.Write(reEmptySecondForm.ReplaceAll(buf.Bytes(), nil))
+	return err
+}
+
+// formatReference returns a reference in the form "path:line".
+func formatReference(ref *po.Reference) string {
+	if ref.Line > 0 {
+		return ref.Path + ":" + strconv.Itoa(ref.Line)
+	}
+	return ref.Path
+}
+
+// parseReference parses a reference of the form "path:line".
+func parseReference(raw string) *po.Reference {
+	if idx := strings.LastIndex(raw, ":"); idx > 0 {
+		if line, err := strconv.Atoi(raw[idx+1:]); err == nil {
+			return &po.Reference{Path: raw[:idx], Line: line}
+		}
+	}
+	return &po.Reference{Path: raw}
+}
+
+// EncodeJSON writes the catalog as spreak JSON file.
+// Plural messages contain a translation for each plural category of the language.
+func EncodeJSON(cat *Catalog, w io.Writer) error {
+	categories := encoder.PluralCategories(cat.Language)
+
+	items := make(map[string]encoder.JSONItem, len(cat.Messages))
+	for _, msg := range cat.Messages {
+		if msg.ID == "" {
+			continue
+		}
+
+		jsonMsg := make(encoder.JSONMessage)
+		if msg.Context != "" {
+			jsonMsg["context"] = msg.Context
+		}
+
+		if msg.IDPlural == "" {
+			jsonMsg[catKey(cldrplural.Other)] = msg.Translations[cldrplural.Other]
+		} else {
+			for _, c := range categories {
+				jsonMsg[catKey(c)] = msg.Translations[c]
+			}
+		}
+
+		key := encoder.JSONKey(msg.Context, msg.ID)
+		items[key] = encoder.JSONItem{Key: key, Message: jsonMsg}
+	}
+
+	file := make(encoder.JSONFile, 0, len(items))
+	for _, item := range items {
+		file = append(file, item)
+	}
+	sort.Slice(file, func(i, j int) bool {
+		return file[i].Key < file[j].Key
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(file)
+}
+
+func catKey(cat cldrplural.Category) string {
+	return strings.ToLower(cat.String())
+}
+
+// parseCategory returns the plural category of a lowercase category name.
+func parseCategory(name string) (cldrplural.Category, bool) {
+	for cat := range cldrplural.CategoryNames {
+		if catKey(cat) == name {
+			return cat, true
+		}
+	}
+	return 0, false
+}
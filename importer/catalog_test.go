@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vorlif/spreak/catalog/cldrplural"
+	"github.com/vorlif/spreak/catalog/po"
+
+	"github.com/vorlif/xspreak/encoder"
+)
+
+func testCatalog() *Catalog {
+	return &Catalog{
+		Language: "pl",
+		Messages: []*Message{
+			{
+				ID:           "Hello %s",
+				Translations: map[cldrplural.Category]string{cldrplural.Other: "Witaj %s"},
+				Comments:     []string{"Greeting"},
+				References:   []string{"main.go:3"},
+				Flags:        []string{"go-format"},
+			},
+			{
+				Context:  "ctx",
+				ID:       "%d file",
+				IDPlural: "%d files",
+				Translations: map[cldrplural.Category]string{
+					cldrplural.One:   "%d plik",
+					cldrplural.Few:   "%d pliki",
+					cldrplural.Many:  "%d plików",
+					cldrplural.Other: "%d pliku",
+				},
+			},
+		},
+	}
+}
+
+func TestEncodePO(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodePO(testCatalog(), &buf))
+
+	out := buf.String()
+	assert.Contains(t, out, `"Language: pl\n"`)
+	assert.Contains(t, out, `"X-spreak-use-CLDR: true\n"`)
+	assert.Contains(t, out, "#. Greeting\n#: main.go:3\n#, go-format\nmsgid \"Hello %s\"\nmsgstr \"Witaj %s\"")
+	assert.Contains(t, out, "msgctxt \"ctx\"\nmsgid \"%d file\"\nmsgid_plural \"%d files\"\n"+
+		"msgstr[0] \"%d plik\"\nmsgstr[1] \"%d pliki\"\nmsgstr[2] \"%d plików\"\nmsgstr[3] \"%d pliku\"")
+
+	file, err := po.Parse(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "true", file.Header.Get(headerUseCLDR))
+	assert.Equal(t, "Witaj %s", file.GetMessage("", "Hello %s").Str[0])
+}
+
+func TestEncodeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodeJSON(testCatalog(), &buf))
+
+	var file encoder.JSONFile
+	require.NoError(t, file.UnmarshalJSON(buf.Bytes()))
+	require.Len(t, file, 2)
+
+	items := make(map[string]encoder.JSONMessage)
+	for _, item := range file {
+		items[item.Key] = item.Message
+	}
+
+	assert.Equal(t, encoder.JSONMessage{"other": "Witaj %s"}, items["Hello %s"])
+	assert.Equal(t, encoder.JSONMessage{
+		"context": "ctx",
+		"one":     "%d plik",
+		"few":     "%d pliki",
+		"many":    "%d plików",
+		"other":   "%d pliku",
+	}, items[encoder.JSONKey("ctx", "%d file")])
+}
+
+func TestDecodePO(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodePO(testCatalog(), &buf))
+
+	cat, err := DecodePO(buf.Bytes(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "pl", cat.Language)
+	require.Len(t, cat.Messages, 2)
+	assert.Equal(t, testCatalog().Messages[0], cat.Messages[0])
+	assert.Equal(t, testCatalog().Messages[1].Translations, cat.Messages[1].Translations)
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodeJSON(testCatalog(), &buf))
+
+	cat, err := DecodeJSON(buf.Bytes(), "pl")
+	require.NoError(t, err)
+	require.Len(t, cat.Messages, 2)
+
+	assert.Equal(t, "Hello %s", cat.Messages[0].ID)
+	assert.Empty(t, cat.Messages[0].IDPlural)
+	assert.Equal(t, "Witaj %s", cat.Messages[0].Translations[cldrplural.Other])
+
+	assert.Equal(t, "ctx", cat.Messages[1].Context)
+	assert.Equal(t, "%d file", cat.Messages[1].ID)
+	assert.Equal(t, "%d file", cat.Messages[1].IDPlural)
+	assert.Equal(t, testCatalog().Messages[1].Translations, cat.Messages[1].Translations)
+}
+
+func TestDecodePOPluralForms(t *testing.T) {
+	const header = `msgid ""
+msgstr ""
+"Language: %s\n"
+"Plural-Forms: %s\n"
+
+msgid "%%d file"
+msgid_plural "%%d files"
+`
+
+	t.Run("french", func(t *testing.T) {
+		data := fmt.Sprintf(header, "fr", "nplurals=2; plural=(n > 1);") +
+			"msgstr[0] \"%d fichier\"\nmsgstr[1] \"%d fichiers\"\n"
+
+		cat, err := DecodePO([]byte(data), "")
+		require.NoError(t, err)
+		require.Len(t, cat.Messages, 1)
+		assert.Equal(t, map[cldrplural.Category]string{
+			cldrplural.One:   "%d fichier",
+			cldrplural.Many:  "%d fichiers",
+			cldrplural.Other: "%d fichiers",
+		}, cat.Messages[0].Translations)
+	})
+
+	t.Run("russian", func(t *testing.T) {
+		data := fmt.Sprintf(header, "ru",
+			"nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);") +
+			"msgstr[0] \"%d файл\"\nmsgstr[1] \"%d файла\"\nmsgstr[2] \"%d файлов\"\n"
+
+		cat, err := DecodePO([]byte(data), "")
+		require.NoError(t, err)
+		require.Len(t, cat.Messages, 1)
+		translations := cat.Messages[0].Translations
+		assert.Equal(t, "%d файл", translations[cldrplural.One])
+		assert.Equal(t, "%d файла", translations[cldrplural.Few])
+		assert.Equal(t, "%d файлов", translations[cldrplural.Many])
+		assert.NotEmpty(t, translations[cldrplural.Other])
+	})
+
+	t.Run("unknown language", func(t *testing.T) {
+		data := fmt.Sprintf(header, "", "nplurals=2; plural=(n != 1);") +
+			"msgstr[0] \"a\"\nmsgstr[1] \"b\"\n"
+
+		_, err := DecodePO([]byte(data), "")
+		assert.ErrorContains(t, err, "set the language")
+	})
+}
@@ -0,0 +1,383 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/vorlif/spreak/catalog/cldrplural"
+
+	"github.com/vorlif/xspreak/encoder"
+)
+
+// CSVError is an error in a row of a CSV file.
+type CSVError struct {
+	// Line is the line in the file at which the row starts.
+	Line int
+	// Column is the name of the invalid column or empty if the error concerns the whole row.
+	Column string
+	Err    error
+}
+
+func (e *CSVError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("line %d, column %s: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *CSVError) Unwrap() error { return e.Err }
+
+// CSVErrors contains the errors of all invalid rows of a CSV file.
+type CSVErrors []*CSVError
+
+func (e CSVErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e CSVErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+var (
+	errLeadingLineBreak  = errors.New("the source and the translation must both begin with a line break or neither")
+	errTrailingLineBreak = errors.New("the source and the translation must both end with a line break or neither")
+	errNoPlural          = errors.New("the message has no plural, only the category other can be translated")
+	errPartlyTranslated  = errors.New("the plural message is partly translated, all plural categories of the language must be translated")
+)
+
+// DecodeCSV reads a CSV file written by encoder.WriteCSV and returns a catalog for each language.
+// All rows are validated, for invalid rows the returned error is of type CSVErrors and the
+// catalogs contain only the valid rows. If the header is invalid, no catalogs are returned.
+func DecodeCSV(data []byte, comma rune) ([]*Catalog, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV file is empty")
+	} else if err != nil {
+		return nil, csvParseError(err)
+	}
+
+	layout, errs := parseCSVHeader(header)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	catalogs := make(map[string]*Catalog, len(layout.languages))
+	for _, lang := range layout.languages {
+		catalogs[lang] = &Catalog{Language: lang}
+	}
+
+	seen := make(map[string]int)
+	for {
+		record, errR := r.Read()
+		if errors.Is(errR, io.EOF) {
+			break
+		} else if errR != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(errR, &parseErr) {
+				return nil, errR
+			}
+			errs = append(errs, csvParseError(errR)...)
+			continue
+		}
+
+		if isEmptyRecord(record) {
+			continue
+		}
+
+		line, _ := r.FieldPos(0)
+		messages, rowErrs := layout.decodeRow(record, line)
+
+		key := layout.value(record, encoder.CSVColumnContext) + "\x04" + layout.value(record, encoder.CSVColumnKey)
+		if first, ok := seen[key]; ok {
+			rowErrs = append(rowErrs, &CSVError{Line: line, Err: fmt.Errorf("duplicate of the message in line %d", first)})
+		} else {
+			seen[key] = line
+		}
+
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		for lang, msg := range messages {
+			catalogs[lang].Messages = append(catalogs[lang].Messages, msg)
+		}
+	}
+
+	result := make([]*Catalog, 0, len(catalogs))
+	for _, lang := range layout.languages {
+		result = append(result, catalogs[lang])
+	}
+
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
+
+func csvParseError(err error) CSVErrors {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return CSVErrors{{Line: parseErr.StartLine, Err: parseErr.Err}}
+	}
+	return CSVErrors{{Line: 1, Err: err}}
+}
+
+func isEmptyRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type csvTranslationColumn struct {
+	name     string
+	lang     string
+	category cldrplural.Category
+}
+
+// csvLayout describes the columns of a CSV file.
+type csvLayout struct {
+	columns      map[string]int
+	translations []csvTranslationColumn
+	languages    []string
+}
+
+func parseCSVHeader(header []string) (*csvLayout, CSVErrors) {
+	layout := &csvLayout{columns: make(map[string]int)}
+	var errs CSVErrors
+	addErr := func(column string, err error) {
+		errs = append(errs, &CSVError{Line: 1, Column: column, Err: err})
+	}
+
+	if len(header) > 0 {
+		// Spreadsheet programs often write a byte order mark.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if _, ok := layout.columns[name]; ok {
+			addErr(name, errors.New("duplicate column"))
+			continue
+		}
+		layout.columns[name] = i
+
+		if slices.Contains(encoder.CSVColumns, name) {
+			continue
+		}
+
+		lang, category, ok := parseTranslationColumn(name)
+		if !ok {
+			addErr(name, errors.New("unknown column"))
+			continue
+		}
+		if _, err := language.Parse(lang); err != nil {
+			addErr(name, fmt.Errorf("invalid language %q", lang))
+			continue
+		}
+		cat, found := parseCategory(category)
+		if !found {
+			addErr(name, fmt.Errorf("unknown plural category %q", category))
+			continue
+		}
+		if !slices.Contains(encoder.PluralCategories(lang), cat) {
+			addErr(name, fmt.Errorf("the language %s has no plural category %q", lang, category))
+			continue
+		}
+
+		layout.translations = append(layout.translations, csvTranslationColumn{name: name, lang: lang, category: cat})
+		if !slices.Contains(layout.languages, lang) {
+			layout.languages = append(layout.languages, lang)
+		}
+	}
+
+	if _, ok := layout.columns[encoder.CSVColumnKey]; !ok {
+		addErr(encoder.CSVColumnKey, errors.New("missing column"))
+	}
+
+	return layout, errs
+}
+
+// parseTranslationColumn splits a column name of the form "lang[category]".
+func parseTranslationColumn(name string) (lang, category string, ok bool) {
+	lang, rest, found := strings.Cut(name, "[")
+	if !found || lang == "" || !strings.HasSuffix(rest, "]") {
+		return "", "", false
+	}
+	return lang, strings.TrimSuffix(rest, "]"), true
+}
+
+// value returns the value of a column or an empty string if the file has no such column.
+func (l *csvLayout) value(record []string, column string) string {
+	if i, ok := l.columns[column]; ok && i < len(record) {
+		return record[i]
+	}
+	return ""
+}
+
+// decodeRow validates a row and returns its message for each language.
+func (l *csvLayout) decodeRow(record []string, line int) (map[string]*Message, CSVErrors) {
+	var errs CSVErrors
+	addErr := func(column string, err error) {
+		errs = append(errs, &CSVError{Line: line, Column: column, Err: err})
+	}
+
+	key := l.value(record, encoder.CSVColumnKey)
+	plural := l.value(record, encoder.CSVColumnPlural)
+	if key == "" {
+		addErr(encoder.CSVColumnKey, errors.New("the key must not be empty"))
+	}
+
+	messages := make(map[string]*Message, len(l.languages))
+	for _, lang := range l.languages {
+		msg := &Message{
+			Context:      l.value(record, encoder.CSVColumnContext),
+			ID:           key,
+			IDPlural:     plural,
+			Translations: make(map[cldrplural.Category]string),
+			Comments:     splitList(l.value(record, encoder.CSVColumnComments), "\n"),
+			References:   splitList(l.value(record, encoder.CSVColumnReferences), "\n"),
+			Flags:        splitList(l.value(record, encoder.CSVColumnFlags), ","),
+		}
+		messages[lang] = msg
+	}
+
+	filled := make(map[string]map[cldrplural.Category]bool, len(l.languages))
+	for _, column := range l.translations {
+		text := l.value(record, column.name)
+		if text == "" {
+			continue
+		}
+		if filled[column.lang] == nil {
+			filled[column.lang] = make(map[cldrplural.Category]bool)
+		}
+		filled[column.lang][column.category] = true
+
+		source := key
+		if plural != "" && column.category != cldrplural.One {
+			source = plural
+		}
+
+		switch {
+		case plural == "" && column.category != cldrplural.Other:
+			addErr(column.name, errNoPlural)
+		case strings.HasPrefix(source, "\n") != strings.HasPrefix(text, "\n"):
+			addErr(column.name, errLeadingLineBreak)
+		case strings.HasSuffix(source, "\n") != strings.HasSuffix(text, "\n"):
+			addErr(column.name, errTrailingLineBreak)
+		default:
+			messages[column.lang].Translations[column.category] = text
+		}
+	}
+
+	if plural != "" {
+		for _, lang := range l.languages {
+			if len(filled[lang]) == 0 {
+				continue
+			}
+			for _, cat := range encoder.PluralCategories(lang) {
+				if !filled[lang][cat] {
+					addErr(encoder.CSVTranslationColumn(lang, cat), errPartlyTranslated)
+				}
+			}
+		}
+	}
+
+	return messages, errs
+}
+
+// splitList splits a list and removes empty elements.
+func splitList(s, sep string) []string {
+	var list []string
+	for _, elem := range strings.Split(s, sep) {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			list = append(list, elem)
+		}
+	}
+	return list
+}
+
+// EncodeCSV writes the catalogs side by side as CSV file.
+// If a template is given, the file contains the messages of the template and
+// translations of messages that are not part of the template are dropped.
+// Otherwise, the file contains the messages of all catalogs.
+func EncodeCSV(w io.Writer, comma rune, template *Catalog, catalogs []*Catalog) error {
+	rows := make(map[string]*encoder.CSVMessage)
+	add := func(msg *Message) *encoder.CSVMessage {
+		row := &encoder.CSVMessage{
+			Context:      msg.Context,
+			Key:          msg.ID,
+			Plural:       msg.IDPlural,
+			Comments:     msg.Comments,
+			References:   msg.References,
+			Translations: make(map[string]map[cldrplural.Category]string),
+		}
+		// The fuzzy flag belongs to a translation and not to the message.
+		for _, flag := range msg.Flags {
+			if flag != flagFuzzy {
+				row.Flags = append(row.Flags, flag)
+			}
+		}
+		rows[msg.Context+"\x04"+msg.ID] = row
+		return row
+	}
+
+	if template != nil {
+		for _, msg := range template.Messages {
+			add(msg)
+		}
+	}
+
+	languages := make([]string, 0, len(catalogs))
+	for _, cat := range catalogs {
+		if slices.Contains(languages, cat.Language) {
+			return fmt.Errorf("the language %q is contained more than once", cat.Language)
+		}
+		languages = append(languages, cat.Language)
+
+		for _, msg := range cat.Messages {
+			row, ok := rows[msg.Context+"\x04"+msg.ID]
+			if !ok {
+				if template != nil {
+					continue
+				}
+				row = add(msg)
+			}
+			row.Translations[cat.Language] = msg.Translations
+		}
+	}
+
+	messages := make([]*encoder.CSVMessage, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, row)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Context != messages[j].Context {
+			return messages[i].Context < messages[j].Context
+		}
+		return messages[i].Key < messages[j].Key
+	})
+
+	return encoder.WriteCSV(w, comma, languages, messages)
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vorlif/spreak/catalog/cldrplural"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	de := &Catalog{
+		Language: "de",
+		Messages: []*Message{
+			{
+				ID:           "Hello\n",
+				Translations: map[cldrplural.Category]string{cldrplural.Other: "Hallo\n"},
+				Flags:        []string{"fuzzy"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeCSV(&buf, ',', nil, []*Catalog{testCatalog(), de}))
+
+	catalogs, err := DecodeCSV(buf.Bytes(), ',')
+	require.NoError(t, err)
+	require.Len(t, catalogs, 2)
+
+	pl := catalogs[0]
+	assert.Equal(t, "pl", pl.Language)
+	require.Len(t, pl.Messages, 3)
+	assert.Equal(t, "Hello\n", pl.Messages[0].ID)
+	assert.Empty(t, pl.Messages[0].Translations)
+	assert.Equal(t, testCatalog().Messages[0], pl.Messages[1])
+	assert.Equal(t, testCatalog().Messages[1].Translations, pl.Messages[2].Translations)
+
+	assert.Equal(t, "de", catalogs[1].Language)
+	assert.Equal(t, "Hallo\n", catalogs[1].Messages[0].Translations[cldrplural.Other])
+	assert.Empty(t, catalogs[1].Messages[0].Flags)
+}
+
+func TestEncodeCSVTemplate(t *testing.T) {
+	template := &Catalog{Messages: []*Message{{ID: "Hello %s", Comments: []string{"From the template"}}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeCSV(&buf, ',', template, []*Catalog{testCatalog()}))
+	assert.Equal(t, "context,key,plural,comments,references,flags,pl[one],pl[few],pl[many],pl[other]\n"+
+		",Hello %s,,From the template,,,,,,Witaj %s\n", buf.String())
+
+	err := EncodeCSV(&buf, ',', nil, []*Catalog{testCatalog(), testCatalog()})
+	assert.ErrorContains(t, err, "more than once")
+}
+
+func TestDecodeCSVErrors(t *testing.T) {
+	t.Run("invalid header", func(t *testing.T) {
+		data := "\ufeffcontext,plural,notes,xx-invalid-[other],de[many],de[unknown]\n"
+		catalogs, err := DecodeCSV([]byte(data), ',')
+		assert.Nil(t, catalogs)
+
+		var errs CSVErrors
+		require.True(t, errors.As(err, &errs))
+		columns := make([]string, 0, len(errs))
+		for _, e := range errs {
+			assert.Equal(t, 1, e.Line)
+			columns = append(columns, e.Column)
+		}
+		assert.Equal(t, []string{"notes", "xx-invalid-[other]", "de[many]", "de[unknown]", "key"}, columns)
+	})
+
+	t.Run("invalid rows", func(t *testing.T) {
+		data := "key\tplural\tde[one]\tde[other]\n" +
+			"valid\t\t\tgültig\n" +
+			"\t\t\tno key\n" +
+			"singular\t\tone\t\n" +
+			"\"line\n\"\t\t\tZeile\n" +
+			"valid\t\t\tdoppelt\n" +
+			"\"quote\"x\t\t\t\n" +
+			"\t\t\t\n" +
+			"fields\n" +
+			"last\t\t\tletzte\n"
+
+		catalogs, err := DecodeCSV([]byte(data), '\t')
+		var errs CSVErrors
+		require.True(t, errors.As(err, &errs))
+
+		lines := make(map[int]string)
+		for _, e := range errs {
+			lines[e.Line] = e.Column
+		}
+		assert.Equal(t, map[int]string{3: "key", 4: "de[one]", 5: "de[other]", 7: "", 8: "", 10: ""}, lines)
+		assert.ErrorIs(t, err, errNoPlural)
+		assert.ErrorIs(t, err, errTrailingLineBreak)
+
+		require.Len(t, catalogs, 1)
+		require.Len(t, catalogs[0].Messages, 2)
+		assert.Equal(t, "gültig", catalogs[0].Messages[0].Translations[cldrplural.Other])
+		assert.Equal(t, "letzte", catalogs[0].Messages[1].Translations[cldrplural.Other])
+	})
+
+	t.Run("partly translated plural", func(t *testing.T) {
+		data := "key,plural,de[one],de[other],fr[one],fr[many],fr[other]\n" +
+			"%d file,%d files,%d Datei,,,,\n" +
+			"%d dir,%d dirs,,%d Ordner,%d dossier,,%d dossiers\n" +
+			"%d item,%d items,%d Eintrag,%d Einträge,,,\n"
+
+		catalogs, err := DecodeCSV([]byte(data), ',')
+		var errs CSVErrors
+		require.True(t, errors.As(err, &errs))
+		assert.ErrorIs(t, err, errPartlyTranslated)
+
+		columns := make([]string, 0, len(errs))
+		for _, e := range errs {
+			columns = append(columns, fmt.Sprintf("%d:%s", e.Line, e.Column))
+		}
+		assert.Equal(t, []string{"2:de[other]", "3:de[one]", "3:fr[many]"}, columns)
+
+		require.Len(t, catalogs, 2)
+		require.Len(t, catalogs[0].Messages, 1)
+		assert.Equal(t, "%d item", catalogs[0].Messages[0].ID)
+		assert.Empty(t, catalogs[1].Messages[0].Translations)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		_, err := DecodeCSV(nil, ',')
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,360 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vorlif/spreak/catalog/cldrplural"
+
+	"github.com/vorlif/xspreak/encoder"
+)
+
+// flagFuzzy marks translations that must be reviewed.
+const flagFuzzy = "fuzzy"
+
+// DecodeXLIFF reads an XLIFF 1.2 or 2.0 file.
+// The plural forms are read from the plural groups written by the XLIFF encoder, the category of a unit
+// is taken from its ID or, if the ID has no category, from its position in the group.
+// If lang is empty, the target language of the file is used.
+func DecodeXLIFF(data []byte, lang string) (*Catalog, error) {
+	var root struct {
+		Version string `xml:"version,attr"`
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("XLIFF file could not be decoded: %w", err)
+	}
+
+	switch {
+	case strings.HasPrefix(root.Version, "1."):
+		return decodeXLIFF12(data, lang)
+	case strings.HasPrefix(root.Version, "2."):
+		return decodeXLIFF20(data, lang)
+	default:
+		return nil, fmt.Errorf("XLIFF version %q is not supported", root.Version)
+	}
+}
+
+type xliff12Document struct {
+	Files []struct {
+		TargetLanguage string        `xml:"target-language,attr"`
+		Body           xliff12Groups `xml:"body"`
+	} `xml:"file"`
+}
+
+type xliff12Groups struct {
+	Units  []xliff12Unit  `xml:"trans-unit"`
+	Groups []xliff12Group `xml:"group"`
+}
+
+type xliff12Group struct {
+	xliff12Groups
+	ID      string        `xml:"id,attr"`
+	Resname string        `xml:"resname,attr"`
+	Restype string        `xml:"restype,attr"`
+	Notes   []xliff12Note `xml:"note"`
+}
+
+type xliff12Unit struct {
+	ID      string `xml:"id,attr"`
+	Resname string `xml:"resname,attr"`
+	Source  string `xml:"source"`
+	Target  struct {
+		State string `xml:"state,attr"`
+		Text  string `xml:",chardata"`
+	} `xml:"target"`
+	Notes         []xliff12Note `xml:"note"`
+	ContextGroups []struct {
+		Purpose  string `xml:"purpose,attr"`
+		Contexts []struct {
+			Type string `xml:"context-type,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"context"`
+	} `xml:"context-group"`
+}
+
+type xliff12Note struct {
+	From string `xml:"from,attr"`
+	Text string `xml:",chardata"`
+}
+
+func decodeXLIFF12(data []byte, lang string) (*Catalog, error) {
+	var doc xliff12Document
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("XLIFF file could not be decoded: %w", err)
+	}
+
+	cat := &Catalog{Language: lang}
+	for _, file := range doc.Files {
+		if cat.Language == "" {
+			cat.Language = file.TargetLanguage
+		}
+	}
+
+	categories := encoder.PluralCategories(cat.Language)
+	for _, file := range doc.Files {
+		cat.addXLIFF12(file.Body, categories)
+	}
+	return cat, nil
+}
+
+func (c *Catalog) addXLIFF12(container xliff12Groups, categories []cldrplural.Category) {
+	for _, unit := range container.Units {
+		msg := &Message{
+			Context:      unit.Resname,
+			ID:           unit.Source,
+			Translations: map[cldrplural.Category]string{cldrplural.Other: unit.Target.Text},
+		}
+		msg.addXLIFF12Unit(unit)
+		c.Messages = append(c.Messages, msg)
+	}
+
+	for _, group := range container.Groups {
+		if group.Restype != encoder.XLIFF12PluralGroup {
+			c.addXLIFF12(group.xliff12Groups, categories)
+			continue
+		}
+
+		forms := newPluralForms(group.Resname)
+		for _, note := range group.Notes {
+			forms.msg.addNote(note.From, note.Text)
+		}
+		for i, unit := range group.Units {
+			if category, ok := pluralCategory(group.ID, unit.ID, i, categories); ok {
+				forms.add(i, category, unit.Source, unit.Target.Text)
+			}
+			if i == 0 {
+				forms.msg.addXLIFF12Unit(unit)
+			}
+		}
+		c.Messages = append(c.Messages, forms.message())
+	}
+}
+
+func (m *Message) addXLIFF12Unit(unit xliff12Unit) {
+	for _, note := range unit.Notes {
+		m.addNote(note.From, note.Text)
+	}
+
+	for _, group := range unit.ContextGroups {
+		if group.Purpose != encoder.XLIFFNoteLocation {
+			continue
+		}
+		var file, line string
+		for _, ctx := range group.Contexts {
+			switch ctx.Type {
+			case "sourcefile":
+				file = ctx.Text
+			case "linenumber":
+				line = ctx.Text
+			}
+		}
+		if file != "" && line != "" {
+			m.References = append(m.References, file+":"+line)
+		} else if file != "" {
+			m.References = append(m.References, file)
+		}
+	}
+
+	if strings.HasPrefix(unit.Target.State, "needs-review") && unit.Target.Text != "" {
+		m.addFlags(flagFuzzy)
+	}
+}
+
+type xliff20Document struct {
+	TrgLang string          `xml:"trgLang,attr"`
+	Files   []xliff20Groups `xml:"file"`
+}
+
+type xliff20Groups struct {
+	Units  []xliff20Unit  `xml:"unit"`
+	Groups []xliff20Group `xml:"group"`
+}
+
+type xliff20Group struct {
+	xliff20Groups
+	ID    string        `xml:"id,attr"`
+	Name  string        `xml:"name,attr"`
+	Type  string        `xml:"type,attr"`
+	Notes []xliff20Note `xml:"notes>note"`
+}
+
+type xliff20Unit struct {
+	ID       string        `xml:"id,attr"`
+	Name     string        `xml:"name,attr"`
+	Notes    []xliff20Note `xml:"notes>note"`
+	Segments []struct {
+		Source string `xml:"source"`
+		Target string `xml:"target"`
+	} `xml:"segment"`
+}
+
+type xliff20Note struct {
+	Category string `xml:"category,attr"`
+	Text     string `xml:",chardata"`
+}
+
+// text returns the source and target text of all segments of a unit.
+func (u xliff20Unit) text() (source, target string) {
+	var src, trg strings.Builder
+	for _, segment := range u.Segments {
+		src.WriteString(segment.Source)
+		trg.WriteString(segment.Target)
+	}
+	return src.String(), trg.String()
+}
+
+func decodeXLIFF20(data []byte, lang string) (*Catalog, error) {
+	var doc xliff20Document
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("XLIFF file could not be decoded: %w", err)
+	}
+
+	cat := &Catalog{Language: lang}
+	if cat.Language == "" {
+		cat.Language = doc.TrgLang
+	}
+
+	categories := encoder.PluralCategories(cat.Language)
+	for _, file := range doc.Files {
+		cat.addXLIFF20(file, categories)
+	}
+	return cat, nil
+}
+
+func (c *Catalog) addXLIFF20(container xliff20Groups, categories []cldrplural.Category) {
+	for _, unit := range container.Units {
+		source, target := unit.text()
+		msg := &Message{
+			Context:      unit.Name,
+			ID:           source,
+			Translations: map[cldrplural.Category]string{cldrplural.Other: target},
+		}
+		for _, note := range unit.Notes {
+			msg.addNote(note.Category, note.Text)
+		}
+		c.Messages = append(c.Messages, msg)
+	}
+
+	for _, group := range container.Groups {
+		if group.Type != encoder.XLIFF20PluralGroup {
+			c.addXLIFF20(group.xliff20Groups, categories)
+			continue
+		}
+
+		forms := newPluralForms(group.Name)
+		for _, note := range group.Notes {
+			forms.msg.addNote(note.Category, note.Text)
+		}
+		for i, unit := range group.Units {
+			if category, ok := pluralCategory(group.ID, unit.ID, i, categories); ok {
+				source, target := unit.text()
+				forms.add(i, category, source, target)
+			}
+		}
+		c.Messages = append(c.Messages, forms.message())
+	}
+}
+
+// pluralCategory returns the plural category of the unit at the index i of a plural group.
+func pluralCategory(groupID, unitID string, i int, categories []cldrplural.Category) (cldrplural.Category, bool) {
+	if name, found := strings.CutPrefix(unitID, groupID+"-"); found {
+		if category, ok := parseCategory(name); ok {
+			return category, true
+		}
+	}
+
+	if idx := strings.LastIndex(unitID, "["); idx >= 0 && strings.HasSuffix(unitID, "]") {
+		if n, err := strconv.Atoi(unitID[idx+1 : len(unitID)-1]); err == nil {
+			i = n
+		}
+	}
+
+	if i < len(categories) {
+		return categories[i], true
+	}
+	return 0, false
+}
+
+// pluralForms collects the plural forms of a plural group.
+type pluralForms struct {
+	msg     *Message
+	sources map[cldrplural.Category]string
+	first   string
+	last    string
+}
+
+func newPluralForms(context string) *pluralForms {
+	return &pluralForms{
+		msg:     &Message{Context: context, Translations: make(map[cldrplural.Category]string)},
+		sources: make(map[cldrplural.Category]string),
+	}
+}
+
+func (p *pluralForms) add(i int, category cldrplural.Category, source, target string) {
+	p.msg.Translations[category] = target
+	p.sources[category] = source
+	if i == 0 {
+		p.first = source
+	}
+	p.last = source
+}
+
+// message returns the message of the group. The msgid is taken from the singular note of the group or
+// the source of the category One and the plural is the source of the category Other.
+// Without these categories, the first and the last source are used.
+func (p *pluralForms) message() *Message {
+	var ok bool
+	if p.msg.ID == "" {
+		if p.msg.ID, ok = p.sources[cldrplural.One]; !ok {
+			p.msg.ID = p.first
+		}
+	}
+	if p.msg.IDPlural, ok = p.sources[cldrplural.Other]; !ok {
+		p.msg.IDPlural = p.last
+	}
+	return p.msg
+}
+
+// addNote adds the content of a note to the comments, references or flags of the message.
+func (m *Message) addNote(category, text string) {
+	if category == encoder.XLIFFNoteSingular {
+		m.ID = text
+		return
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	switch category {
+	case encoder.XLIFFNoteLocation:
+		m.References = append(m.References, text)
+	case encoder.XLIFFNoteFlags:
+		m.addFlags(strings.Split(text, ",")...)
+	default:
+		m.Comments = append(m.Comments, text)
+	}
+}
+
+func (m *Message) addFlags(flags ...string) {
+	for _, flag := range flags {
+		flag = strings.TrimSpace(flag)
+		if flag == "" {
+			continue
+		}
+		exists := false
+		for _, f := range m.Flags {
+			if f == flag {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			m.Flags = append(m.Flags, flag)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"bytes"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vorlif/spreak/catalog/cldrplural"
+
+	"github.com/vorlif/xspreak/config"
+	"github.com/vorlif/xspreak/encoder"
+	"github.com/vorlif/xspreak/extract"
+)
+
+const testXLIFF12 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">
+  <file original="messages" datatype="plaintext" source-language="en" target-language="de">
+    <body>
+      <trans-unit id="hello" maxwidth="20" size-unit="char">
+        <source>Hello %s</source>
+        <target state="needs-review-translation">Hallo %s</target>
+        <context-group purpose="location">
+          <context context-type="sourcefile">main.go</context>
+          <context context-type="linenumber">3</context>
+        </context-group>
+        <note from="developer">Greeting</note>
+        <note from="flags">go-format, max-length:20</note>
+      </trans-unit>
+      <group id="files" resname="ctx" restype="x-gettext-plurals">
+        <trans-unit id="files[0]" resname="ctx">
+          <source>%d file</source>
+          <target>%d Datei</target>
+        </trans-unit>
+        <trans-unit id="files[1]" resname="ctx">
+          <source>%d files</source>
+          <target>%d Dateien</target>
+        </trans-unit>
+      </group>
+    </body>
+  </file>
+</xliff>
+`
+
+const testXLIFF20 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="pl">
+  <file id="messages">
+    <unit id="hello">
+      <notes>
+        <note category="developer">Greeting</note>
+        <note category="location">main.go:3</note>
+      </notes>
+      <segment>
+        <source>Hello %s</source>
+        <target>Witaj %s</target>
+      </segment>
+    </unit>
+    <group id="files" name="ctx" type="xspreak:plural">
+      <notes>
+        <note category="flags">go-format</note>
+      </notes>
+      <unit id="files-one" name="ctx"><segment><source>%d file</source><target>%d plik</target></segment></unit>
+      <unit id="files-few" name="ctx"><segment><source>%d files</source><target>%d pliki</target></segment></unit>
+      <unit id="files-many" name="ctx"><segment><source>%d files</source><target>%d plików</target></segment></unit>
+      <unit id="files-other" name="ctx"><segment><source>%d files</source><target>%d pliku</target></segment></unit>
+    </group>
+  </file>
+</xliff>
+`
+
+func TestDecodeXLIFF12(t *testing.T) {
+	cat, err := DecodeXLIFF([]byte(testXLIFF12), "")
+	require.NoError(t, err)
+	assert.Equal(t, "de", cat.Language)
+	require.Len(t, cat.Messages, 2)
+
+	hello := cat.Messages[0]
+	assert.Equal(t, "Hello %s", hello.ID)
+	assert.Empty(t, hello.Context)
+	assert.Equal(t, "Hallo %s", hello.Translations[cldrplural.Other])
+	assert.Equal(t, []string{"Greeting"}, hello.Comments)
+	assert.Equal(t, []string{"main.go:3"}, hello.References)
+	assert.Equal(t, []string{"go-format", "max-length:20", "fuzzy"}, hello.Flags)
+
+	files := cat.Messages[1]
+	assert.Equal(t, "ctx", files.Context)
+	assert.Equal(t, "%d file", files.ID)
+	assert.Equal(t, "%d files", files.IDPlural)
+	assert.Equal(t, map[cldrplural.Category]string{
+		cldrplural.One:   "%d Datei",
+		cldrplural.Other: "%d Dateien",
+	}, files.Translations)
+}
+
+func TestDecodeXLIFF20(t *testing.T) {
+	cat, err := DecodeXLIFF([]byte(testXLIFF20), "")
+	require.NoError(t, err)
+	assert.Equal(t, "pl", cat.Language)
+	require.Len(t, cat.Messages, 2)
+
+	hello := cat.Messages[0]
+	assert.Equal(t, "Hello %s", hello.ID)
+	assert.Equal(t, "Witaj %s", hello.Translations[cldrplural.Other])
+	assert.Equal(t, []string{"Greeting"}, hello.Comments)
+	assert.Equal(t, []string{"main.go:3"}, hello.References)
+
+	files := cat.Messages[1]
+	assert.Equal(t, "ctx", files.Context)
+	assert.Equal(t, "%d file", files.ID)
+	assert.Equal(t, "%d files", files.IDPlural)
+	assert.Equal(t, []string{"go-format"}, files.Flags)
+	assert.Equal(t, "%d plik", files.Translations[cldrplural.One])
+	assert.Equal(t, "%d pliki", files.Translations[cldrplural.Few])
+	assert.Equal(t, "%d plików", files.Translations[cldrplural.Many])
+	assert.Equal(t, "%d pliku", files.Translations[cldrplural.Other])
+}
+
+func TestDecodeXLIFFLanguage(t *testing.T) {
+	cat, err := DecodeXLIFF([]byte(testXLIFF20), "de")
+	require.NoError(t, err)
+	assert.Equal(t, "de", cat.Language)
+
+	// German only knows the categories one and other, the remaining units are assigned by their ID
+	files := cat.Messages[1]
+	assert.Equal(t, "%d plik", files.Translations[cldrplural.One])
+	assert.Equal(t, "%d pliku", files.Translations[cldrplural.Other])
+}
+
+func TestDecodeXLIFFErrors(t *testing.T) {
+	_, err := DecodeXLIFF([]byte("<xliff"), "")
+	assert.Error(t, err)
+
+	_, err = DecodeXLIFF([]byte(`<xliff version="3.0"></xliff>`), "")
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestDecodeXLIFFEncoderIDs(t *testing.T) {
+	id := encoder.XLIFFUnitID("ctx", "%d file")
+	data := `<xliff version="1.2"><file target-language="de"><body>
+<group id="` + id + `" resname="ctx" restype="` + encoder.XLIFF12PluralGroup + `">
+<trans-unit id="` + encoder.XLIFFPluralUnitID(id, cldrplural.Other) + `"><source>%d files</source><target>%d Dateien</target></trans-unit>
+<trans-unit id="` + encoder.XLIFFPluralUnitID(id, cldrplural.One) + `"><source>%d file</source><target>%d Datei</target></trans-unit>
+</group></body></file></xliff>`
+
+	cat, err := DecodeXLIFF([]byte(data), "")
+	require.NoError(t, err)
+	require.Len(t, cat.Messages, 1)
+	assert.Equal(t, "%d file", cat.Messages[0].ID)
+	assert.Equal(t, "%d files", cat.Messages[0].IDPlural)
+	assert.Equal(t, "%d Datei", cat.Messages[0].Translations[cldrplural.One])
+	assert.Equal(t, "%d Dateien", cat.Messages[0].Translations[cldrplural.Other])
+}
+
+func TestXLIFFRoundTripOneForm(t *testing.T) {
+	// Japanese has only the plural category other, so the group contains no unit with the singular
+	for _, version := range []string{config.XLIFFVersion12, config.XLIFFVersion20} {
+		t.Run(version, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := config.NewDefault()
+			cfg.OutputDir = dir
+			cfg.XLIFFVersion = version
+			cfg.TargetLanguage = "ja"
+			require.NoError(t, cfg.Prepare())
+
+			issues := []extract.Issue{{
+				MsgID:    "one apple",
+				PluralID: "%d apples",
+				Pos:      token.Position{Filename: filepath.Join(dir, "main.go"), Line: 3},
+			}}
+			var buf bytes.Buffer
+			require.NoError(t, encoder.NewXLIFFEncoder(cfg, &buf).Encode(issues))
+
+			translated := strings.Replace(buf.String(), "<source>%d apples</source>", "<source>%d apples</source><target>リンゴ%d個</target>", 1)
+			cat, err := DecodeXLIFF([]byte(translated), "")
+			require.NoError(t, err)
+			require.Len(t, cat.Messages, 1)
+			assert.Equal(t, "one apple", cat.Messages[0].ID)
+			assert.Equal(t, "%d apples", cat.Messages[0].IDPlural)
+			assert.Equal(t, map[cldrplural.Category]string{cldrplural.Other: "リンゴ%d個"}, cat.Messages[0].Translations)
+
+			buf.Reset()
+			require.NoError(t, EncodePO(cat, &buf))
+			assert.Contains(t, buf.String(), "msgid \"one apple\"\nmsgid_plural \"%d apples\"\nmsgstr[0] \"リンゴ%d個\"\n")
+			assert.NotContains(t, buf.String(), "msgstr[1]")
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package merger
 
 import (
+	"bytes"
 	"encoding/json"
 	"sort"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/vorlif/spreak/catalog/cldrplural"
 
 	"github.com/vorlif/xspreak/encoder"
+	"github.com/vorlif/xspreak/nestedjson"
 )
 
 func MergeJSON(src []byte, dst []byte, cats []cldrplural.Category) []byte {
@@ -105,6 +107,37 @@ func MergeJSON(src []byte, dst []byte, cats []cldrplural.Category) []byte {
 	return data
 }
 
+// MergeNestedJSON merges JSON files in the nested layout, see MergeJSON.
+// The source and the target file can be nested or flat, the result is always nested.
+func MergeNestedJSON(src []byte, dst []byte, cats []cldrplural.Category, sep string) []byte {
+	if len(src) == 0 {
+		log.Fatal("Source file is empty")
+	}
+
+	flatSrc, err := nestedjson.Flatten(src, sep)
+	if err != nil {
+		log.WithError(err).Fatal("Source file could not be decoded")
+	}
+
+	var flatDst []byte
+	if len(dst) > 0 {
+		if flatDst, err = nestedjson.Flatten(dst, sep); err != nil {
+			log.WithError(err).Fatal("Target file could not be decoded")
+		}
+	}
+
+	nested, err := nestedjson.Nest(MergeJSON(flatSrc, flatDst, cats), sep)
+	if err != nil {
+		log.WithError(err).Fatal("Keys could not be nested")
+	}
+
+	var buf bytes.Buffer
+	if err = json.Indent(&buf, nested, "", "  "); err != nil {
+		log.WithError(err).Fatal("Marshal failed")
+	}
+	return buf.Bytes()
+}
+
 func catKey(cat cldrplural.Category) string {
 	return strings.ToLower(cat.String())
 }
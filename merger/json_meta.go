@@ -0,0 +1,76 @@
+package merger
+
+import (
+	"encoding/json"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vorlif/xspreak/encoder"
+)
+
+// flagFuzzy is the only flag that belongs to the translation and is kept by the merge.
+const flagFuzzy = "fuzzy"
+
+// MergeJSONMeta merges the metadata of a source file into the metadata of a target file.
+// The comments, references and flags are refreshed from the source metadata, while the translator
+// comments and the fuzzy flag of the target are preserved. Without source metadata, the target
+// metadata is kept. Only the messages of the source file src are kept.
+func MergeJSONMeta(src []byte, srcMeta []byte, dstMeta []byte) []byte {
+	var sourceFile encoder.JSONFile
+	if err := json.Unmarshal(src, &sourceFile); err != nil {
+		log.WithError(err).Fatal("Source file could not be decoded")
+	}
+
+	sourceMeta := decodeMeta(srcMeta, "Source")
+	targetMeta := decodeMeta(dstMeta, "Target")
+
+	meta := make(encoder.JSONMeta, len(sourceFile))
+	for _, item := range sourceFile {
+		srcItem, hasSrc := sourceMeta[item.Key]
+		dstItem, hasDst := targetMeta[item.Key]
+
+		var merged encoder.JSONMetaItem
+		switch {
+		case sourceMeta == nil && hasDst:
+			merged = *dstItem
+		case hasSrc:
+			merged = encoder.JSONMetaItem{
+				Comments:   srcItem.Comments,
+				References: srcItem.References,
+				Flags:      slices.Clone(srcItem.Flags),
+			}
+		}
+
+		if hasDst {
+			merged.TranslatorComments = dstItem.TranslatorComments
+			if slices.Contains(dstItem.Flags, flagFuzzy) && !slices.Contains(merged.Flags, flagFuzzy) {
+				merged.Flags = append(merged.Flags, flagFuzzy)
+				slices.Sort(merged.Flags)
+			}
+		}
+
+		if len(merged.Comments)+len(merged.TranslatorComments)+len(merged.References)+len(merged.Flags) > 0 {
+			meta[item.Key] = &merged
+		}
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		log.WithError(err).Fatal("Marshal failed")
+	}
+	return data
+}
+
+// decodeMeta decodes a metadata file, it returns nil for an empty file.
+func decodeMeta(data []byte, name string) encoder.JSONMeta {
+	if len(data) == 0 {
+		return nil
+	}
+
+	meta := make(encoder.JSONMeta)
+	if err := json.Unmarshal(data, &meta); err != nil {
+		log.WithError(err).Fatalf("%s metadata could not be decoded", name)
+	}
+	return meta
+}
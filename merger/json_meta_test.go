@@ -0,0 +1,40 @@
+package merger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeJSONMeta(t *testing.T) {
+	src := []byte(`{"a": "", "b": "", "c": ""}`)
+
+	t.Run("metadata is refreshed and translations are preserved", func(t *testing.T) {
+		srcMeta := []byte(`{
+"a": {"comments": ["new comment"], "references": ["a.go:2"], "flags": ["go-format"]},
+"b": {"references": ["b.go:1"]}
+}`)
+		dstMeta := []byte(`{
+"a": {"comments": ["old comment"], "translatorComments": ["check this"], "references": ["a.go:1"], "flags": ["fuzzy", "no-wrap"]},
+"c": {"translatorComments": ["kept"]},
+"removed": {"translatorComments": ["gone"]}
+}`)
+
+		want := `{
+"a": {"comments": ["new comment"], "translatorComments": ["check this"], "references": ["a.go:2"], "flags": ["fuzzy", "go-format"]},
+"b": {"references": ["b.go:1"]},
+"c": {"translatorComments": ["kept"]}
+}`
+		assert.JSONEq(t, want, string(MergeJSONMeta(src, srcMeta, dstMeta)))
+	})
+
+	t.Run("new metadata file is created", func(t *testing.T) {
+		srcMeta := []byte(`{"a": {"comments": ["comment"]}, "unknown": {"comments": ["ignored"]}}`)
+		assert.JSONEq(t, `{"a": {"comments": ["comment"]}}`, string(MergeJSONMeta(src, srcMeta, nil)))
+	})
+
+	t.Run("target metadata is kept without source metadata", func(t *testing.T) {
+		dstMeta := []byte(`{"b": {"comments": ["comment"], "flags": ["fuzzy"]}, "removed": {"comments": ["gone"]}}`)
+		assert.JSONEq(t, `{"b": {"comments": ["comment"], "flags": ["fuzzy"]}}`, string(MergeJSONMeta(src, nil, dstMeta)))
+	})
+}
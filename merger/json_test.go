@@ -50,3 +50,22 @@ func TestMergeJson(t *testing.T) {
 		assert.JSONEq(t, want, string(res))
 	})
 }
+
+func TestMergeNestedJSON(t *testing.T) {
+	src := []byte(`{"settings.title": "", "settings.items": {"one": "", "other": ""}, "home": ""}`)
+	dst := []byte(`{"settings": {"title": "Einstellungen", "old": "Alt"}}`)
+
+	res := MergeNestedJSON(src, dst, []cldrplural.Category{cldrplural.One, cldrplural.Other}, ".")
+
+	want := `{
+  "home": "",
+  "settings": {
+    "items": {
+      "one": "",
+      "other": ""
+    },
+    "title": "Einstellungen"
+  }
+}`
+	assert.Equal(t, want, string(res))
+}
@@ -0,0 +1,253 @@
+// Package nestedjson converts spreak JSON files between the flat layout and a nested layout,
+// in which the keys are split at a separator into nested objects.
+//
+// With the separator "." the flat file
+//
+//	{"settings.profile.title": "Profile", "settings.profile.save": "Save"}
+//
+// is written as
+//
+//	{"settings": {"profile": {"save": "Save", "title": "Profile"}}}
+//
+// An object is a message if all its keys are "context" or plural categories and all its values are strings,
+// otherwise it is a branch. A message key that is also the prefix of other keys cannot be nested and
+// is reported as ConflictError.
+package nestedjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+
+	"github.com/vorlif/spreak/catalog"
+)
+
+// DefaultSeparator is the separator of the keys used by default.
+const DefaultSeparator = "."
+
+// messageKeys are the keys of a message object.
+var messageKeys = []string{"context", "zero", "one", "two", "few", "many", "other"}
+
+var errEmptySeparator = errors.New("the separator must not be empty")
+
+// ConflictError reports a key that is used as message and as branch, or a branch that would be read as message.
+type ConflictError struct {
+	// Key is the key of the message or of the branch.
+	Key string
+	// Other is a key that has Key as prefix. It is empty if the branch would be read as message.
+	Other string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Other == "" {
+		return fmt.Sprintf("the children of %q cannot be distinguished from a plural message", e.Key)
+	}
+	return fmt.Sprintf("the key %q is a message and a prefix of the key %q", e.Key, e.Other)
+}
+
+// node is a message or a branch of a nested file.
+type node struct {
+	// key is the flat key of the message or the first key below the branch.
+	key string
+	// path is the key of the branch.
+	path     string
+	value    json.RawMessage
+	children map[string]*node
+}
+
+func (n *node) isBranch() bool { return n.children != nil }
+
+// Nest converts a flat JSON file into a nested JSON file.
+// The keys of the objects are sorted and the result is not indented.
+func Nest(data []byte, sep string) ([]byte, error) {
+	if sep == "" {
+		return nil, errEmptySeparator
+	}
+
+	var flat map[string]json.RawMessage
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := &node{children: make(map[string]*node)}
+	for _, key := range keys {
+		parts := strings.Split(key, sep)
+		current := root
+		for i, part := range parts[:len(parts)-1] {
+			child, ok := current.children[part]
+			if !ok {
+				child = &node{key: key, path: strings.Join(parts[:i+1], sep), children: make(map[string]*node)}
+				current.children[part] = child
+			} else if !child.isBranch() {
+				return nil, &ConflictError{Key: child.key, Other: key}
+			}
+			current = child
+		}
+
+		last := parts[len(parts)-1]
+		if child, ok := current.children[last]; ok {
+			return nil, &ConflictError{Key: key, Other: child.key}
+		}
+		current.children[last] = &node{key: key, value: flat[key]}
+	}
+
+	var buf bytes.Buffer
+	if err := root.write(&buf, true); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *node) write(buf *bytes.Buffer, isRoot bool) error {
+	if !n.isBranch() {
+		buf.Write(n.value)
+		return nil
+	}
+
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if !isRoot && n.looksLikeMessage(names) {
+		return &ConflictError{Key: n.path}
+	}
+
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err = n.children[name].write(buf, false); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// looksLikeMessage reports whether the branch would be read as message.
+func (n *node) looksLikeMessage(names []string) bool {
+	for _, name := range names {
+		child := n.children[name]
+		if !slices.Contains(messageKeys, name) || child.isBranch() || !isString(child.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Flatten converts a nested JSON file into a flat JSON file.
+// Flat files are returned unchanged, apart from the order of the keys.
+func Flatten(data []byte, sep string) ([]byte, error) {
+	if sep == "" {
+		return nil, errEmptySeparator
+	}
+
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]json.RawMessage)
+	if err := flatten(flat, nil, root, sep); err != nil {
+		return nil, err
+	}
+	return json.Marshal(flat)
+}
+
+func flatten(flat map[string]json.RawMessage, path []string, obj map[string]json.RawMessage, sep string) error {
+	for name, value := range obj {
+		childPath := append(slices.Clip(path), name)
+		key := strings.Join(childPath, sep)
+
+		isMsg, err := isMessage(value)
+		if err != nil {
+			return fmt.Errorf("invalid value of %q: %w", key, err)
+		}
+		if isMsg {
+			if _, ok := flat[key]; ok {
+				return fmt.Errorf("the key %q is defined more than once", key)
+			}
+			flat[key] = value
+			continue
+		}
+
+		var children map[string]json.RawMessage
+		if err = json.Unmarshal(value, &children); err != nil {
+			return err
+		}
+		if err = flatten(flat, childPath, children, sep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isMessage reports whether a value is a message, i.e. a string or an object of strings with message keys.
+func isMessage(value json.RawMessage) (bool, error) {
+	value = bytes.TrimSpace(value)
+	if isString(value) {
+		return true, nil
+	}
+	if len(value) == 0 || value[0] != '{' {
+		return false, errors.New("a message must be a string or an object")
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return false, err
+	}
+	for key, v := range obj {
+		if !slices.Contains(messageKeys, key) || !isString(v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isString(value json.RawMessage) bool {
+	value = bytes.TrimSpace(value)
+	return len(value) > 0 && value[0] == '"'
+}
+
+// Decoder is a spreak decoder for JSON files in the nested or the flat layout.
+// It can be registered with spreak.WithDecoder(".json", nestedjson.NewDecoder(".")).
+type Decoder struct {
+	sep  string
+	json *catalog.JSONDecoder
+}
+
+var _ catalog.Decoder = (*Decoder)(nil)
+
+// NewDecoder creates a decoder that flattens the keys with the separator before they are loaded.
+func NewDecoder(sep string) *Decoder {
+	return &Decoder{sep: sep, json: catalog.NewJSONDecoder()}
+}
+
+func (d *Decoder) Decode(lang language.Tag, domain string, data []byte) (catalog.Catalog, error) {
+	flat, err := Flatten(data, d.sep)
+	if err != nil {
+		return nil, err
+	}
+	return d.json.Decode(lang, domain, flat)
+}
@@ -0,0 +1,102 @@
+package nestedjson
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+const testFlat = `{
+	"settings.profile.title": "Profil",
+	"settings.profile.items": {"one": "%d Eintrag", "other": "%d Einträge"},
+	"settings.save_ctx": {"context": "ctx", "other": "Speichern"},
+	"other": "Sonstiges"
+}`
+
+const testNested = `{
+	"other": "Sonstiges",
+	"settings": {
+		"profile": {
+			"items": {"one": "%d Eintrag", "other": "%d Einträge"},
+			"title": "Profil"
+		},
+		"save_ctx": {"context": "ctx", "other": "Speichern"}
+	}
+}`
+
+func TestNest(t *testing.T) {
+	t.Run("keys are split", func(t *testing.T) {
+		nested, err := Nest([]byte(testFlat), ".")
+		require.NoError(t, err)
+		assert.JSONEq(t, testNested, string(nested))
+	})
+
+	t.Run("custom separator", func(t *testing.T) {
+		nested, err := Nest([]byte(`{"a/b": "x", "a.c": "y"}`), "/")
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":{"b":"x"},"a.c":"y"}`, string(nested))
+	})
+
+	t.Run("leaf and branch conflict", func(t *testing.T) {
+		_, err := Nest([]byte(`{"a.b": "x", "a": "y", "a.c": "z"}`), ".")
+		var conflict *ConflictError
+		require.True(t, errors.As(err, &conflict))
+		assert.Equal(t, &ConflictError{Key: "a", Other: "a.b"}, conflict)
+	})
+
+	t.Run("branch that looks like a message", func(t *testing.T) {
+		_, err := Nest([]byte(`{"count.one": "x", "count.other": "y"}`), ".")
+		var conflict *ConflictError
+		require.True(t, errors.As(err, &conflict))
+		assert.Equal(t, &ConflictError{Key: "count"}, conflict)
+	})
+
+	t.Run("empty separator", func(t *testing.T) {
+		_, err := Nest([]byte(testFlat), "")
+		assert.Error(t, err)
+	})
+}
+
+func TestFlatten(t *testing.T) {
+	t.Run("nested file", func(t *testing.T) {
+		flat, err := Flatten([]byte(testNested), ".")
+		require.NoError(t, err)
+		assert.JSONEq(t, testFlat, string(flat))
+	})
+
+	t.Run("flat file is unchanged", func(t *testing.T) {
+		flat, err := Flatten([]byte(testFlat), ".")
+		require.NoError(t, err)
+		assert.JSONEq(t, testFlat, string(flat))
+	})
+
+	t.Run("duplicate key", func(t *testing.T) {
+		_, err := Flatten([]byte(`{"a.b": "x", "a": {"b": "y", "c": {"d": "z"}}}`), ".")
+		assert.ErrorContains(t, err, "more than once")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := Flatten([]byte(`{"a": {"b": 1}}`), ".")
+		assert.ErrorContains(t, err, `"a.b"`)
+	})
+}
+
+func TestDecoder(t *testing.T) {
+	cat, err := NewDecoder(".").Decode(language.German, "messages", []byte(testNested))
+	require.NoError(t, err)
+
+	text, err := cat.Lookup("", "settings.profile.title")
+	require.NoError(t, err)
+	assert.Equal(t, "Profil", text)
+
+	text, err = cat.LookupPlural("", "settings.profile.items", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "%d Einträge", text)
+
+	text, err = cat.Lookup("ctx", "settings.save")
+	require.NoError(t, err)
+	assert.Equal(t, "Speichern", text)
+}
@@ -1,5 +1,10 @@
 package main
 
+import (
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
 // xspreak: template
 var t = `
 {{.T.Get "Hello"}}
@@ -16,3 +21,13 @@ const constTemplate = `
 
 // xspreak: template
 const multiline = "{{   .T.Get `Multiline String\nwith\n  newlines` }}"
+
+const parsedTemplate = `{{.T.Get "Parsed template"}}`
+
+var (
+	textTmpl = texttemplate.Must(texttemplate.New("text").Parse(parsedTemplate))
+	htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Parse(`{{.T.Get "Parsed html template"}}`))
+
+	// xspreak: no-template
+	optOutTmpl = texttemplate.Must(texttemplate.New("opt-out").Parse(`{{.T.Get "Not a template"}}`))
+)
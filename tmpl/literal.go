@@ -0,0 +1,67 @@
+package tmpl
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// unquoteWithOffsets interprets a Go string literal like strconv.Unquote.
+// In addition, for each byte of the result the offset of the originating byte in the literal is returned.
+// The last entry of the offsets holds the offset of the closing quote.
+func unquoteWithOffsets(literal string) (string, []int, error) {
+	n := len(literal)
+	if n < 2 || literal[0] != literal[n-1] {
+		return "", nil, strconv.ErrSyntax
+	}
+
+	var b strings.Builder
+	b.Grow(n)
+	offsets := make([]int, 0, n)
+
+	switch literal[0] {
+	case '`':
+		for i := 1; i < n-1; i++ {
+			// Carriage returns are discarded from raw strings
+			if literal[i] == '\r' {
+				continue
+			}
+			b.WriteByte(literal[i])
+			offsets = append(offsets, i)
+		}
+	case '"':
+		rest := literal[1 : n-1]
+		offset := 1
+		for len(rest) > 0 {
+			value, multibyte, tail, err := strconv.UnquoteChar(rest, '"')
+			if err != nil {
+				return "", nil, err
+			}
+
+			start := b.Len()
+			if value < utf8.RuneSelf || !multibyte {
+				b.WriteByte(byte(value))
+			} else {
+				b.WriteRune(value)
+			}
+
+			isEscape := rest[0] == '\\'
+			for i := start; i < b.Len(); i++ {
+				if isEscape {
+					// All bytes of an escape sequence point to the backslash
+					offsets = append(offsets, offset)
+				} else {
+					offsets = append(offsets, offset+i-start)
+				}
+			}
+
+			offset += len(rest) - len(tail)
+			rest = tail
+		}
+	default:
+		return "", nil, strconv.ErrSyntax
+	}
+
+	offsets = append(offsets, n-1)
+	return b.String(), offsets, nil
+}
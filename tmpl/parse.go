@@ -1,13 +1,14 @@
 package tmpl
 
 import (
-	"bufio"
-	"bytes"
+	"errors"
 	"go/token"
-	"io"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"text/template/parse"
+	"unicode/utf8"
 )
 
 type Template struct {
@@ -19,10 +20,39 @@ type Template struct {
 	GoFilePos token.Position
 
 	// OffsetLookup holds the first position of all line starts.
+	// For inline templates created with ParseLiteral these are the line starts of the Go file.
 	OffsetLookup []token.Position
 
-	// Comments holds the comments of each line number
-	Comments map[int][]string
+	// src holds the text to which the positions refer.
+	src []byte
+	// srcOffsets maps each byte offset of the template to the byte offset in src.
+	// It is only set for inline templates created with ParseLiteral.
+	srcOffsets []int
+
+	// Comments holds the comment groups of the template ordered by their position.
+	// It is filled by ExtractComments.
+	Comments     []*CommentGroup
+	commentsOnce sync.Once
+}
+
+// CommentGroup represents a sequence of template comments with no other lines between them.
+//
+// Example:
+//
+//	{{/* TRANSLATORS: The first line */}}
+//	{{/* and the second line form a group */}}
+type CommentGroup struct {
+	// StartLine is the line on which the first comment begins.
+	StartLine int
+	// EndLine is the line on which the last comment ends.
+	EndLine int
+	// List contains the text of each comment without the comment markers.
+	List []string
+}
+
+// Text returns the text of all comments of the group separated by newlines.
+func (g *CommentGroup) Text() string {
+	return strings.Join(g.List, "\n")
 }
 
 func ParseFile(filepath string) (*Template, error) {
@@ -39,10 +69,52 @@ func ParseString(name, content string) (*Template, error) {
 }
 
 func ParseBytes(name string, src []byte) (*Template, error) {
+	t, err := parseTemplate(name, string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	t.src = src
+	t.OffsetLookup = extractLineInfos(name, src)
+
+	return t, nil
+}
+
+// ParseLiteral parses a template that is defined as a string literal in a Go file.
+// src is the content of the Go file, offset is the byte offset of the literal in src and
+// literal is the literal including its quotes as it appears in the source code.
+// The positions of the template refer to the Go file.
+func ParseLiteral(filename string, src []byte, offset int, literal string) (*Template, error) {
+	if offset < 0 || offset+len(literal) > len(src) || string(src[offset:offset+len(literal)]) != literal {
+		return nil, errors.New("literal not found in source")
+	}
+
+	content, offsets, errU := unquoteWithOffsets(literal)
+	if errU != nil {
+		return nil, errU
+	}
+
+	t, err := parseTemplate(filename, content)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range offsets {
+		offsets[i] += offset
+	}
+
+	t.src = src
+	t.srcOffsets = offsets
+	t.OffsetLookup = extractLineInfos(filename, src)
+	t.GoFilePos = t.sourcePosition(offset)
+
+	return t, nil
+}
+
+func parseTemplate(name, text string) (*Template, error) {
 	t := &Template{
 		Filename:  name,
 		Trees:     make(map[string]*parse.Tree),
-		Comments:  make(map[int][]string),
 		Inspector: nil,
 	}
 
@@ -51,7 +123,7 @@ func ParseBytes(name string, src []byte) (*Template, error) {
 		Mode: parse.ParseComments | parse.SkipFuncCheck,
 	}
 
-	_, err := tree.Parse(string(src), "{{", "}}", t.Trees, map[string]any{})
+	_, err := tree.Parse(text, "{{", "}}", t.Trees, map[string]any{})
 	if err != nil {
 		return nil, err
 	}
@@ -61,81 +133,130 @@ func ParseBytes(name string, src []byte) (*Template, error) {
 		roodNotes = append(roodNotes, tree.Root)
 	}
 
-	t.OffsetLookup = extractLineInfos(name, bytes.NewReader(src))
 	t.Inspector = newInspector(roodNotes)
 
 	return t, nil
 }
 
-func extractLineInfos(filename string, src io.Reader) []token.Position {
+// extractLineInfos returns the positions of all line starts of src.
+// Lines are terminated by '\n', so that a '\r' of a CRLF line ending belongs to the line it terminates.
+func extractLineInfos(filename string, src []byte) []token.Position {
 	infos := make([]token.Position, 0, 50)
 	infos = append(infos, token.Position{Filename: filename, Offset: 0, Line: 1, Column: 1})
 
-	scanner := bufio.NewScanner(src)
-	offset := 0
 	line := 1
-	for scanner.Scan() {
-		offset += len(scanner.Text())
-		infos = append(infos, token.Position{Filename: filename, Offset: offset, Line: line, Column: len(scanner.Text())})
-		offset++
+	for offset, b := range src {
+		if b != '\n' {
+			continue
+		}
+
 		line++
+		infos = append(infos, token.Position{Filename: filename, Offset: offset + 1, Line: line, Column: 1})
 	}
 
 	return infos
 }
 
+// ExtractComments collects all comments of the template and combines comments
+// on consecutive lines into comment groups, as Go does for line comments.
+// The comments are collected only once, so it is safe to call it from several goroutines.
 func (t *Template) ExtractComments() {
+	t.commentsOnce.Do(t.extractComments)
+}
+
+func (t *Template) extractComments() {
+	nodes := make([]*parse.CommentNode, 0)
 	t.Inspector.Nodes([]parse.Node{&parse.CommentNode{}}, func(rawNode parse.Node, _ bool) (proceed bool) {
 		proceed = false
-		node := rawNode.(*parse.CommentNode)
+		nodes = append(nodes, rawNode.(*parse.CommentNode))
+		return
+	})
 
+	// The nodes of the different trees are not ordered
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Pos < nodes[j].Pos })
+
+	t.Comments = make([]*CommentGroup, 0, len(nodes))
+	var current *CommentGroup
+	for _, node := range nodes {
 		comment := strings.TrimSpace(node.Text)
 		comment = strings.TrimPrefix(comment, "/*")
 		comment = strings.TrimSuffix(comment, "*/")
 		comment = strings.TrimSpace(comment)
 
-		pos := t.Position(node.Pos)
-		t.Comments[pos.Line] = append(t.Comments[pos.Line], comment)
+		startLine := t.Position(node.Pos).Line
+		endLine := t.Position(node.Pos + parse.Pos(len(node.Text))).Line
 
-		return
-	})
+		if current != nil && startLine <= current.EndLine+1 {
+			current.EndLine = endLine
+			current.List = append(current.List, comment)
+			continue
+		}
+
+		current = &CommentGroup{StartLine: startLine, EndLine: endLine, List: []string{comment}}
+		t.Comments = append(t.Comments, current)
+	}
 }
 
+// Position returns the position of a template offset.
+// The column is counted in runes, the offset in bytes.
+// For inline templates the position refers to the Go file in which the template is defined.
 func (t *Template) Position(offset parse.Pos) token.Position {
-	var pos token.Position
-	pos.Filename = t.Filename
+	if t.srcOffsets != nil {
+		return t.sourcePosition(t.sourceOffset(int(offset)))
+	}
 
-	for i, p := range t.OffsetLookup {
-		if p.Offset > int(offset) {
-			pos = t.OffsetLookup[i-1]
-			break
+	pos := t.sourcePosition(int(offset))
+	if t.GoFilePos.IsValid() && pos.IsValid() {
+		// The exact origin in the Go file is unknown, we can only approximate the position.
+		if pos.Line == 1 {
+			pos.Column += t.GoFilePos.Column - 1
 		}
+		pos.Filename = t.GoFilePos.Filename
+		pos.Line += t.GoFilePos.Line - 1
+		pos.Offset += t.GoFilePos.Offset
 	}
 
-	if pos.IsValid() {
-		pos.Column = int(offset) - pos.Offset
-		pos.Offset += int(offset)
+	return pos
+}
+
+// sourceOffset maps an offset of the template to the byte offset in the Go file.
+func (t *Template) sourceOffset(offset int) int {
+	if offset < 0 {
+		return t.srcOffsets[0]
+	}
+	if offset >= len(t.srcOffsets) {
+		return t.srcOffsets[len(t.srcOffsets)-1]
 	}
+	return t.srcOffsets[offset]
+}
 
-	if t.GoFilePos.IsValid() {
-		pos.Filename = t.GoFilePos.Filename
-		pos.Line += t.GoFilePos.Line - 1
-		pos.Column += t.GoFilePos.Column - 1
-		pos.Offset += t.GoFilePos.Offset
+// sourcePosition returns the position of a byte offset in src.
+func (t *Template) sourcePosition(offset int) token.Position {
+	if len(t.OffsetLookup) == 0 {
+		return token.Position{Filename: t.Filename}
 	}
 
+	offset = max(0, min(offset, len(t.src)))
+	idx := sort.Search(len(t.OffsetLookup), func(i int) bool {
+		return t.OffsetLookup[i].Offset > offset
+	})
+
+	pos := t.OffsetLookup[max(idx-1, 0)]
+	pos.Column = utf8.RuneCount(t.src[pos.Offset:offset]) + 1
+	pos.Offset = offset
+
 	return pos
 }
 
+// GetComments returns the text of all comment groups that belong to the given position.
+// A comment group belongs to a position if it ends on the same line or on the line before.
 func (t *Template) GetComments(offset parse.Pos) []string {
-	pos := t.Position(offset)
+	line := t.Position(offset).Line
 	var comments []string
-	if _, ok := t.Comments[pos.Line]; ok {
-		comments = append(comments, t.Comments[pos.Line]...)
-	}
-
-	if _, ok := t.Comments[pos.Line-1]; ok {
-		comments = append(comments, t.Comments[pos.Line-1]...)
+	for _, group := range t.Comments {
+		if group.StartLine <= line && group.EndLine >= line-1 {
+			comments = append(comments, group.Text())
+		}
 	}
 
 	return comments
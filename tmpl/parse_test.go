@@ -2,7 +2,11 @@ package tmpl
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"text/template/parse"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +41,33 @@ multiline */}}
 	assert.Len(t, res.Comments, 0)
 	res.ExtractComments()
 	assert.Len(t, res.Comments, 3)
+
+	// Calling it again does not duplicate the comments
+	res.ExtractComments()
+	require.Len(t, res.Comments, 3)
+	assert.Equal(t, 3, res.Comments[1].StartLine)
+	assert.Equal(t, 5, res.Comments[1].EndLine)
+}
+
+func TestGetComments(t *testing.T) {
+	text := `{{/* first */}}
+{{/* second */}}
+{{.T.Get "hello"}}
+
+{{/* unrelated */}}
+
+{{.T.Get "world"}} {{/* same line */}}
+`
+	res, err := ParseBytes("test", []byte(text))
+	require.NoError(t, err)
+	res.ExtractComments()
+	require.Len(t, res.Comments, 3)
+
+	hello := parse.Pos(strings.Index(text, `"hello"`))
+	assert.Equal(t, []string{"first\nsecond"}, res.GetComments(hello))
+
+	world := parse.Pos(strings.Index(text, `"world"`))
+	assert.Equal(t, []string{"same line"}, res.GetComments(world))
 }
 
 func TestParseHtml(t *testing.T) {
@@ -49,3 +80,105 @@ func TestParseHtml(t *testing.T) {
 	assert.NoError(t, err)
 	require.NotNil(t, res)
 }
+
+func TestPosition(t *testing.T) {
+	t.Run("CRLF line endings", func(t *testing.T) {
+		text := "first\r\nsecond\r\n  {{.T.Get \"hello\"}}\r\n"
+		res, err := ParseBytes("test", []byte(text))
+		require.NoError(t, err)
+
+		pos := res.Position(parse.Pos(strings.Index(text, `"hello"`)))
+		assert.Equal(t, 3, pos.Line)
+		assert.Equal(t, 12, pos.Column)
+		assert.Equal(t, strings.Index(text, `"hello"`), pos.Offset)
+	})
+
+	t.Run("long lines", func(t *testing.T) {
+		text := strings.Repeat("a", 100*1024) + "\n{{.T.Get \"hello\"}}"
+		res, err := ParseBytes("test", []byte(text))
+		require.NoError(t, err)
+
+		pos := res.Position(parse.Pos(strings.Index(text, `"hello"`)))
+		assert.Equal(t, 2, pos.Line)
+		assert.Equal(t, 10, pos.Column)
+	})
+
+	t.Run("multi-byte characters", func(t *testing.T) {
+		text := "äöü €\nGrüße {{.T.Get \"hello\"}}"
+		res, err := ParseBytes("test", []byte(text))
+		require.NoError(t, err)
+
+		pos := res.Position(parse.Pos(strings.Index(text, `"hello"`)))
+		assert.Equal(t, 2, pos.Line)
+		assert.Equal(t, 16, pos.Column)
+		assert.Equal(t, strings.Index(text, `"hello"`), pos.Offset)
+	})
+}
+
+func TestParseLiteral(t *testing.T) {
+	t.Run("raw string", func(t *testing.T) {
+		src := "package main\r\n\r\nvar t = `\r\n  {{.T.Get \"hello\"}}`\r\n"
+		literal := "`\r\n  {{.T.Get \"hello\"}}`"
+		res, err := ParseLiteral("main.go", []byte(src), strings.Index(src, literal), literal)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, res.GoFilePos.Line)
+		assert.Equal(t, 9, res.GoFilePos.Column)
+
+		var node *parse.StringNode
+		res.Inspector.Preorder([]parse.Node{&parse.StringNode{}}, func(n parse.Node) { node = n.(*parse.StringNode) })
+		require.NotNil(t, node)
+
+		pos := res.Position(node.Position())
+		assert.Equal(t, "main.go", pos.Filename)
+		assert.Equal(t, 4, pos.Line)
+		assert.Equal(t, 12, pos.Column)
+		assert.Equal(t, strings.Index(src, `"hello"`), pos.Offset)
+	})
+
+	t.Run("interpreted string with escape sequences", func(t *testing.T) {
+		src := "package main\n\nconst t = \"\\u00e4\\t\\\"ü\\\" {{.T.Get `hello`}}\"\n"
+		literal := "\"\\u00e4\\t\\\"ü\\\" {{.T.Get `hello`}}\""
+		res, err := ParseLiteral("main.go", []byte(src), strings.Index(src, literal), literal)
+		require.NoError(t, err)
+
+		var node *parse.StringNode
+		res.Inspector.Preorder([]parse.Node{&parse.StringNode{}}, func(n parse.Node) { node = n.(*parse.StringNode) })
+		require.NotNil(t, node)
+
+		pos := res.Position(node.Position())
+		offset := strings.Index(src, "`hello`")
+		assert.Equal(t, 3, pos.Line)
+		assert.Equal(t, utf8.RuneCountInString(src[strings.LastIndex(src[:offset], "\n")+1:offset])+1, pos.Column)
+		assert.Equal(t, offset, pos.Offset)
+	})
+
+	t.Run("literal must match the source", func(t *testing.T) {
+		_, err := ParseLiteral("main.go", []byte(`var t = "a"`), 0, `"a"`)
+		assert.Error(t, err)
+	})
+}
+
+func TestUnquoteWithOffsets(t *testing.T) {
+	literals := []string{
+		`"hello"`,
+		"`raw\r\nstring`",
+		`"esc\x41\101ä\U0001F600\n\\"`,
+		`"äöü"`,
+		`""`,
+	}
+
+	for _, literal := range literals {
+		want, err := strconv.Unquote(literal)
+		require.NoError(t, err)
+
+		got, offsets, err := unquoteWithOffsets(literal)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.Len(t, offsets, len(got)+1)
+		assert.Equal(t, len(literal)-1, offsets[len(offsets)-1])
+	}
+
+	_, _, err := unquoteWithOffsets(`"broken`)
+	assert.Error(t, err)
+}
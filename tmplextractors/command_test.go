@@ -186,3 +186,48 @@ func TestParenthesised(t *testing.T) {
 	got := collectIssueStrings(issues)
 	assert.ElementsMatch(t, want, got)
 }
+
+func TestCommentRules(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.SourceDir = testdataDir
+	cfg.TemplatePatterns = []string{
+		testdataTemplates + "/**/eight.comments",
+	}
+
+	require.NoError(t, cfg.Prepare())
+
+	ctx := context.Background()
+	contextLoader := loader.NewPackageLoader(cfg)
+
+	extractCtx, err := contextLoader.Load(ctx)
+	require.NoError(t, err)
+
+	runner, err := runner.New(cfg, extractCtx.Packages)
+	require.NoError(t, err)
+
+	issues, err := runner.Run(ctx, extractCtx, []extract.Extractor{NewCommandExtractor()})
+	require.NoError(t, err)
+
+	byID := make(map[string]extract.Issue, len(issues))
+	for _, iss := range issues {
+		byID[iss.MsgID] = iss
+	}
+
+	assert.NotContains(t, byID, "ignored")
+	if assert.Contains(t, byID, "multiline comment") {
+		assert.Equal(t, []string{"TRANSLATORS: A comment spanning two lines"}, byID["multiline comment"].Comments)
+	}
+	if assert.Contains(t, byID, "comment block") {
+		assert.Equal(t, []string{"TRANSLATORS: A comment block which continues here"}, byID["comment block"].Comments)
+	}
+	if assert.Contains(t, byID, "no comment") {
+		assert.Empty(t, byID["no comment"].Comments)
+	}
+	if assert.Contains(t, byID, "no translator comment") {
+		assert.Empty(t, byID["no translator comment"].Comments)
+	}
+	if assert.Contains(t, byID, "range flag") {
+		assert.Equal(t, []string{"TRANSLATORS: With flags"}, byID["range flag"].Comments)
+		assert.Contains(t, byID["range flag"].Flags, "range: 1..5")
+	}
+}
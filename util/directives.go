@@ -0,0 +1,186 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Directives holds the values of a comment starting with "xspreak:".
+//
+// The comment contains a comma separated list of entries:
+//
+//	// xspreak: context=menu, domain=admin
+//	// xspreak: no-go-format, max-length:20
+//	// xspreak: range: 1..5, flag=my-custom-flag
+//
+// Entries of the form key=value set a property, values containing commas can be quoted.
+// Entries of the form name:value and single words are flags, which are written to the PO file.
+type Directives struct {
+	// Context is the message context, it is only set if HasContext is true.
+	Context    string
+	HasContext bool
+	// Domain is the domain of the message, it is only set if HasDomain is true.
+	Domain    string
+	HasDomain bool
+	// Flags are the flags of the message, e.g. "ignore", "no-go-format" or "max-length:20".
+	Flags []string
+	// Markers are the directives that are evaluated elsewhere, e.g. "template" or "ignore-file".
+	Markers []string
+	// Warnings contains a description of each invalid or unknown entry.
+	Warnings []string
+}
+
+var (
+	reFormatFlag = regexp.MustCompile(`^(no-)?[a-z0-9+#-]+-format$`)
+	reFlagName   = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	reNumber     = regexp.MustCompile(`^\d+$`)
+)
+
+// markerDirectives are known directives without effect on the message, they are evaluated elsewhere.
+var markerDirectives = map[string]bool{
+	templateMarkerLong:     true,
+	templateMarkerShort:    true,
+	noTemplateMarkerLong:   true,
+	noTemplateMarkerShort:  true,
+	ignoreFileDirective:    true,
+	ignorePackageDirective: true,
+	ignoreStartDirective:   true,
+	ignoreEndDirective:     true,
+}
+
+// flagDirectives are the known flags without a value.
+var flagDirectives = map[string]bool{
+	"ignore":  true,
+	"wrap":    true,
+	"no-wrap": true,
+}
+
+// ParseDirectives parses a comment line of the form "xspreak: entry, entry, ...".
+func ParseDirectives(line string) *Directives {
+	d := &Directives{}
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(strings.ToLower(line), flagPrefix) {
+		return d
+	}
+
+	for _, entry := range splitEntries(line[len(flagPrefix):]) {
+		d.parseEntry(entry)
+	}
+
+	return d
+}
+
+func (d *Directives) parseEntry(entry string) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return
+	}
+
+	if key, value, ok := strings.Cut(entry, "="); ok && !strings.Contains(key, ":") {
+		d.parseProperty(strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value))
+		return
+	}
+
+	if name, value, ok := strings.Cut(entry, ":"); ok {
+		d.parseValueFlag(strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value))
+		return
+	}
+
+	word := strings.ToLower(entry)
+	switch {
+	case markerDirectives[word]:
+		d.Markers = append(d.Markers, word)
+	case flagDirectives[word], reFormatFlag.MatchString(word):
+		d.addFlag(word)
+	default:
+		d.warnf("unknown directive %q", entry)
+	}
+}
+
+func (d *Directives) parseProperty(key, value string) {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+
+	switch key {
+	case "context", "ctx":
+		d.Context, d.HasContext = value, true
+	case "domain":
+		if value == "" {
+			d.warnf("the domain must not be empty")
+			return
+		}
+		d.Domain, d.HasDomain = value, true
+	case "flag":
+		if value == "" {
+			d.warnf("the flag must not be empty")
+			return
+		}
+		d.addFlag(value)
+	default:
+		d.warnf("unknown key %q", key)
+	}
+}
+
+func (d *Directives) parseValueFlag(name, value string) {
+	switch name {
+	case "range":
+		if !reRange.MatchString("range: " + value) {
+			d.warnf("invalid range %q, expected the form range: 1..5", value)
+			return
+		}
+		d.addFlag("range: " + value)
+	case "max-length":
+		if !reNumber.MatchString(value) {
+			d.warnf("invalid max-length %q, expected a number", value)
+			return
+		}
+		d.addFlag(name + ":" + value)
+	default:
+		if !reFlagName.MatchString(name) || value == "" {
+			d.warnf("invalid flag %q", name+":"+value)
+			return
+		}
+		d.addFlag(name + ":" + value)
+	}
+}
+
+func (d *Directives) addFlag(flag string) {
+	for _, existing := range d.Flags {
+		if existing == flag {
+			return
+		}
+	}
+	d.Flags = append(d.Flags, flag)
+}
+
+func (d *Directives) warnf(format string, args ...any) {
+	d.Warnings = append(d.Warnings, fmt.Sprintf(format, args...))
+}
+
+// splitEntries splits the entries at commas outside of double quotes.
+func splitEntries(s string) []string {
+	var entries []string
+	var b strings.Builder
+	inQuotes := false
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ',' && !inQuotes:
+			entries = append(entries, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return append(entries, b.String())
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		line     string
+		want     Directives
+		warnings int
+	}{
+		{line: "xspreak: ignore", want: Directives{Flags: []string{"ignore"}}},
+		{line: "xspreak: range: 1..6", want: Directives{Flags: []string{"range: 1..6"}}},
+		{line: "xspreak: context=menu, domain=admin", want: Directives{Context: "menu", HasContext: true, Domain: "admin", HasDomain: true}},
+		{line: `xspreak: ctx="Menu, top"`, want: Directives{Context: "Menu, top", HasContext: true}},
+		{line: "xspreak: no-go-format, max-length:20", want: Directives{Flags: []string{"no-go-format", "max-length:20"}}},
+		{line: "xspreak: GO-FORMAT, python-format", want: Directives{Flags: []string{"go-format", "python-format"}}},
+		{line: "xspreak: flag=custom, flag=custom", want: Directives{Flags: []string{"custom"}}},
+		{line: "xspreak: template", want: Directives{Markers: []string{"template"}}},
+		{line: "xspreak: ignore-start", want: Directives{Markers: []string{"ignore-start"}}},
+		{line: "xspreak: colour=red", warnings: 1},
+		{line: "xspreak: ignor", warnings: 1},
+		{line: "xspreak: range: 1...6, max-length:a", warnings: 2},
+		{line: "xspreak: domain=", warnings: 1},
+		{line: "TRANSLATORS: context=menu", want: Directives{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			d := ParseDirectives(tt.line)
+			assert.Len(t, d.Warnings, tt.warnings)
+			d.Warnings = nil
+			if tt.warnings == 0 {
+				assert.Equal(t, tt.want, *d)
+			}
+		})
+	}
+}
@@ -1,8 +1,9 @@
 package util
 
 import (
-	"fmt"
+	"bytes"
 	"regexp"
+	"slices"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -12,41 +13,60 @@ const (
 	flagPrefix          = "xspreak:"
 	templateMarkerLong  = "template"
 	templateMarkerShort = "tmpl"
+
+	noTemplateMarkerLong  = "no-template"
+	noTemplateMarkerShort = "no-tmpl"
 )
 
 var reRange = regexp.MustCompile(`^range:\s+\d+\.\.\d+\s*$`)
 
+// IsInlineTemplate reports whether the comment marks an inline template.
+//
+// Example:
+//
+//	// xspreak: template
+//	const tmpl = `{{ .T.Get "Hello" }}`
 func IsInlineTemplate(comment string) bool {
+	return hasMarker(comment, templateMarkerLong, templateMarkerShort)
+}
+
+// IsNoInlineTemplate reports whether the comment contains the opt-out marker
+// for the automatic detection of inline templates.
+//
+// Example:
+//
+//	// xspreak: no-template
+//	t := template.Must(template.New("").Parse(content))
+func IsNoInlineTemplate(comment string) bool {
+	return hasMarker(comment, noTemplateMarkerLong, noTemplateMarkerShort)
+}
+
+// ContainsFlags reports whether the source code contains a comment with xspreak flags.
+// It is a fast check that can report false positives.
+func ContainsFlags(src []byte) bool {
+	return bytes.Contains(bytes.ToLower(src), []byte(flagPrefix))
+}
+
+// hasMarker reports whether a line of the comment starting with "xspreak:" contains one of the markers as entry.
+func hasMarker(comment string, markers ...string) bool {
 	for _, line := range strings.Split(comment, "\n") {
-		line = strings.ToLower(strings.TrimSpace(line))
-		if strings.HasPrefix(line, flagPrefix) && (strings.Contains(line, templateMarkerLong) || strings.Contains(line, templateMarkerShort)) {
-			return true
+		for _, marker := range ParseDirectives(line).Markers {
+			if slices.Contains(markers, marker) {
+				return true
+			}
 		}
 	}
 
 	return false
 }
 
+// ParseFlags returns the flags of a comment line starting with "xspreak:".
+// Invalid and unknown entries are logged, see ParseDirectives.
 func ParseFlags(line string) []string {
-	possibleFlags := strings.Split(strings.TrimPrefix(line, flagPrefix), ",")
-	flags := make([]string, 0, len(possibleFlags))
-	for _, flag := range possibleFlags {
-		flag = strings.ToLower(strings.TrimSpace(flag))
-
-		if strings.HasPrefix(flag, "range:") {
-			if !reRange.MatchString(flag) {
-				log.WithField("input", flag).Warn("Invalid range flag")
-				continue
-			}
-
-			rangeFlag := fmt.Sprintf("range: %s", strings.TrimSpace(strings.TrimPrefix(flag, "range:")))
-			flags = append(flags, rangeFlag)
-		}
-
-		if flag == "ignore" {
-			flags = append(flags, flag)
-		}
+	d := ParseDirectives(line)
+	for _, warning := range d.Warnings {
+		log.WithField("input", line).Warn(warning)
 	}
 
-	return flags
+	return d.Flags
 }
@@ -25,3 +25,28 @@ func TestRangRe(t *testing.T) {
 		tt.assertionFunc(t, reRange.MatchString(tt.text))
 	}
 }
+
+func TestIsInlineTemplate(t *testing.T) {
+	tests := []struct {
+		comment      string
+		isTemplate   bool
+		isNoTemplate bool
+	}{
+		{"xspreak: template", true, false},
+		{"xspreak: tmpl", true, false},
+		{"TRANSLATORS: a comment\nxspreak: template", true, false},
+		{"xspreak: no-template", false, true},
+		{"xspreak: no-tmpl", false, true},
+		{"a template", false, false},
+		{"xspreak: context=template", false, false},
+		{"xspreak: domain=tmpl-admin", false, false},
+		{"xspreak: context=no-template", false, false},
+		{"xspreak: go-format, Template", true, false},
+		{"xspreak: template\nxspreak: no-tmpl", true, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.isTemplate, IsInlineTemplate(tt.comment), tt.comment)
+		assert.Equal(t, tt.isNoTemplate, IsNoInlineTemplate(tt.comment), tt.comment)
+	}
+}
@@ -0,0 +1,181 @@
+package util
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"math"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vorlif/xspreak/tmpl"
+)
+
+const (
+	ignoreFileDirective    = "ignore-file"
+	ignorePackageDirective = "ignore-package"
+	ignoreStartDirective   = "ignore-start"
+	ignoreEndDirective     = "ignore-end"
+)
+
+// IgnoreDirectives holds the ignore directives of a Go or template file.
+//
+// Example:
+//
+//	//xspreak:ignore-file
+//	//xspreak:ignore-package
+//	// xspreak: ignore-start
+//	...
+//	// xspreak: ignore-end
+type IgnoreDirectives struct {
+	// File is set if the whole file is ignored.
+	File bool
+	// Package is set if all Go files of the package are ignored.
+	Package bool
+	// Regions contains the first and the last line of the ignored regions.
+	// A region without an end reaches to the end of the file.
+	Regions [][2]int
+}
+
+// ParseIgnoreDirectives searches the comments of a Go or template file for ignore directives.
+// Go files are recognized by their extension, all other files are parsed as templates.
+// The file directive is only recognized in the comments before the package clause or the first template node.
+func ParseIgnoreDirectives(filename string, src []byte) *IgnoreDirectives {
+	d := &IgnoreDirectives{}
+	if !ContainsFlags(src) {
+		return d
+	}
+
+	var comments []fileComment
+	if strings.HasSuffix(filename, ".go") {
+		comments = goComments(filename, src)
+	} else {
+		comments = templateComments(filename, src)
+	}
+
+	start := 0
+	for _, comment := range comments {
+		for i, line := range strings.Split(comment.text, "\n") {
+			line = strings.TrimLeft(strings.TrimSpace(line), "* ")
+			for _, marker := range ParseDirectives(line).Markers {
+				switch marker {
+				case ignoreFileDirective:
+					d.File = d.File || comment.header
+				case ignorePackageDirective:
+					d.Package = true
+				case ignoreStartDirective:
+					if start == 0 {
+						start = comment.line + i
+					}
+				case ignoreEndDirective:
+					if start != 0 {
+						d.Regions = append(d.Regions, [2]int{start, comment.line + i})
+						start = 0
+					}
+				}
+			}
+		}
+	}
+
+	if start != 0 {
+		d.Regions = append(d.Regions, [2]int{start, math.MaxInt})
+	}
+
+	return d
+}
+
+// fileComment is a comment of a Go or template file.
+type fileComment struct {
+	// text is the text of the comment without the comment markers.
+	text string
+	// line is the line on which the comment begins.
+	line int
+	// header is set if the comment is before the package clause or the first template node.
+	header bool
+}
+
+// goComments returns the comments of a Go file.
+func goComments(filename string, src []byte) []fileComment {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if file == nil {
+		log.WithError(err).Debugf("Comments of %s could not be parsed", filename)
+		return nil
+	}
+
+	var comments []fileComment
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			comments = append(comments, fileComment{
+				text:   stripCommentMarkers(c.Text),
+				line:   fset.Position(c.Pos()).Line,
+				header: c.End() < file.Package,
+			})
+		}
+	}
+	return comments
+}
+
+// templateComments returns the comments of a template file.
+func templateComments(filename string, src []byte) []fileComment {
+	t, err := tmpl.ParseBytes(filename, src)
+	if err != nil {
+		log.WithError(err).Debugf("Comments of %s could not be parsed", filename)
+		return nil
+	}
+
+	// The header ends with the first node that is neither a comment nor white space
+	var nodes []*parse.CommentNode
+	firstNode := parse.Pos(math.MaxInt)
+	t.Inspector.Preorder(nil, func(node parse.Node) {
+		switch v := node.(type) {
+		case *parse.CommentNode:
+			nodes = append(nodes, v)
+		case *parse.ListNode:
+		case *parse.TextNode:
+			if len(bytes.TrimSpace(v.Text)) > 0 {
+				firstNode = min(firstNode, v.Pos)
+			}
+		default:
+			firstNode = min(firstNode, node.Position())
+		}
+	})
+
+	// The nodes of the different trees are not ordered
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Pos < nodes[j].Pos })
+
+	comments := make([]fileComment, 0, len(nodes))
+	for _, node := range nodes {
+		comments = append(comments, fileComment{
+			text:   stripCommentMarkers(strings.TrimSpace(node.Text)),
+			line:   t.Position(node.Pos).Line,
+			header: node.Pos < firstNode,
+		})
+	}
+	return comments
+}
+
+func stripCommentMarkers(text string) string {
+	if line, ok := strings.CutPrefix(text, "//"); ok {
+		return line
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+}
+
+// IsIgnored reports whether a line of the file is ignored.
+func (d *IgnoreDirectives) IsIgnored(line int) bool {
+	if d.File || d.Package {
+		return true
+	}
+
+	for _, region := range d.Regions {
+		if line >= region[0] && line <= region[1] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIgnoreDirectives(t *testing.T) {
+	t.Run("go file", func(t *testing.T) {
+		src := `//xspreak:ignore-package
+package main
+
+// xspreak: ignore-start
+var a = "a"
+// XSPREAK: IGNORE-END
+
+var b = "// xspreak: ignore-file"
+
+var c = ` + "`\n// xspreak: ignore-start\n`" + `
+
+/* xspreak: ignore-start */
+var d = "d"
+`
+
+		d := ParseIgnoreDirectives("main.go", []byte(src))
+		assert.False(t, d.File, "directives in strings must be ignored")
+		assert.True(t, d.Package)
+		assert.Equal(t, [][2]int{{4, 6}, {14, math.MaxInt}}, d.Regions)
+
+		d.Package = false
+		assert.False(t, d.IsIgnored(2))
+		assert.True(t, d.IsIgnored(5))
+		assert.False(t, d.IsIgnored(8))
+		assert.False(t, d.IsIgnored(11))
+		assert.True(t, d.IsIgnored(15))
+	})
+
+	t.Run("go file header", func(t *testing.T) {
+		d := ParseIgnoreDirectives("gen.go", []byte("// Code generated. DO NOT EDIT.\n\n//xspreak:ignore-file\npackage gen\n"))
+		assert.True(t, d.File)
+		assert.True(t, d.IsIgnored(5))
+
+		d = ParseIgnoreDirectives("main.go", []byte("package main\n\n//xspreak:ignore-file\nvar a = 1\n"))
+		assert.False(t, d.File, "the file directive must be written before the package clause")
+	})
+
+	t.Run("template", func(t *testing.T) {
+		src := `{{/* xspreak: ignore-file */}}
+<p>{{ .T.Get "a" }}</p>
+`
+		d := ParseIgnoreDirectives("page.html", []byte(src))
+		assert.True(t, d.File)
+		assert.True(t, d.IsIgnored(2))
+
+		src = `<p>{{ .T.Get "a" }}</p>
+{{/* xspreak: ignore-file */}}
+<!-- xspreak: ignore-start -->
+{{/* xspreak: ignore-start */}}
+{{ .T.Get "b" }}
+{{/* xspreak: ignore-end */}}
+`
+		d = ParseIgnoreDirectives("page.html", []byte(src))
+		assert.False(t, d.File, "the file directive must be written before the first template node")
+		assert.Equal(t, [][2]int{{4, 6}}, d.Regions)
+	})
+
+	assert.Equal(t, &IgnoreDirectives{}, ParseIgnoreDirectives("main.go", []byte("package main\n")))
+}
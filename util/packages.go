@@ -0,0 +1,49 @@
+package util
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// NewPackageMatcher creates a matcher for a package pattern.
+// Patterns starting with "." or absolute paths match the directories of the packages,
+// all other patterns match the import paths. "..." matches any string.
+// As with the arguments of xspreak, patterns containing a path separator also match the directories.
+func NewPackageMatcher(sourceDir, pattern string) func(pkg *packages.Package) bool {
+	matchPath := matchPattern(pattern)
+	isDir := strings.HasPrefix(pattern, ".") || filepath.IsAbs(pattern)
+	if !isDir && !strings.ContainsRune(pattern, filepath.Separator) {
+		return func(pkg *packages.Package) bool { return matchPath(pkg.PkgPath) }
+	}
+
+	dirPattern := filepath.ToSlash(filepath.Clean(pattern))
+	if !filepath.IsAbs(pattern) {
+		dirPattern = filepath.ToSlash(filepath.Join(sourceDir, pattern))
+	}
+	matchDir := matchPattern(dirPattern)
+
+	return func(pkg *packages.Package) bool {
+		if !isDir && matchPath(pkg.PkgPath) {
+			return true
+		}
+		if len(pkg.GoFiles) == 0 {
+			return false
+		}
+		return matchDir(filepath.ToSlash(filepath.Dir(pkg.GoFiles[0])))
+	}
+}
+
+// matchPattern works like the pattern matching of the go command: "..." matches any string
+// and a trailing "/..." also matches the path without it.
+func matchPattern(pattern string) func(name string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	reg := regexp.MustCompile(`^` + re + `$`)
+	return reg.MatchString
+}
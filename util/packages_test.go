@@ -0,0 +1,18 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	match := matchPattern("example.com/app/...")
+	assert.True(t, match("example.com/app"))
+	assert.True(t, match("example.com/app/sub"))
+	assert.False(t, match("example.com/application"))
+
+	match = matchPattern("example.com/.../cmd")
+	assert.True(t, match("example.com/app/cmd"))
+	assert.False(t, match("example.com/app/cmd/sub"))
+}